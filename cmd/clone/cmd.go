@@ -15,46 +15,418 @@
 package clone
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 
+	"github.com/google/go-github/v47/github"
 	"github.com/spf13/cobra"
 
+	"github.com/jmrodri/gh2jira/internal/annotate"
+	"github.com/jmrodri/gh2jira/internal/config"
 	"github.com/jmrodri/gh2jira/internal/gh"
 	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
 )
 
 var (
-	dryRun    bool
-	project   string
-	ghproject string
+	dryRun       bool
+	project      string
+	ghprojects   []string
+	parallel     int
+	onExisting   string
+	jiraURL      string
+	withComments bool
+	epic         string
+	milestone    string
+	assignee     string
+	label        []string
+	withPRs      bool
+	failFast     bool
+	templateName string
+	planFile     string
+	applyFile    string
+	annotateGH   bool
+	attachments  bool
 )
 
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "clone <ISSUE_ID> [ISSUE_ID ...]",
+		Use:   "clone [ISSUE_ID | ORG/REPO#ISSUE_ID | ISSUE_URL ...]",
 		Short: "Clone given Github issues to Jira",
-		Long:  "Clone given Github issues to Jira. WARNING! This will write to your jira instance. Use --dryrun to see what will happen",
-		Args:  cobra.MinimumNArgs(1),
+		Long: "Clone given Github issues or pull requests, or all open issues matching the --milestone, " +
+			"--assignee and --label filters, to Jira. WARNING! This will write to your jira instance. " +
+			"Use --dryrun to see what will happen",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			for _, id := range args {
-				issueId, _ := strconv.Atoi(id)
-				issue, err := gh.GetIssue(issueId, gh.WithProject(ghproject))
-				if err != nil {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("project") {
+				project = cfg.Jira.Project
+			}
+			if !cmd.Flags().Changed("github-project") {
+				ghprojects = []string{cfg.Github.Project}
+			}
+			if !cmd.Flags().Changed("jira-url") {
+				jiraURL = cfg.Jira.URL
+			}
+			if !cmd.Flags().Changed("epic") {
+				epic = cfg.Jira.Epic
+			}
+
+			filtered := milestone != "" || assignee != "" || len(label) > 0
+			if applyFile != "" {
+				if filtered || len(args) > 0 {
+					return fmt.Errorf("--apply clones the issues of the plan, give no issue ids or filters")
+				}
+				cmd.SilenceUsage = true
+				return applyPlan(cmd, cfg)
+			}
+			if filtered && len(args) > 0 {
+				return fmt.Errorf("give either issue ids or --milestone, --assignee and --label filters, not both")
+			}
+			if !filtered && len(args) == 0 {
+				return fmt.Errorf("give at least one issue id or a --milestone, --assignee or --label filter")
+			}
+
+			// check every issue before anything is written to Jira
+			refs, err := parseIssueRefs(args, ghprojects)
+			if err != nil {
+				return err
+			}
+			if templateName != "" {
+				if _, err := tmpl.Select(cfg.Templates, templateName, ""); err != nil {
+					return err
+				}
+			}
+			// the arguments are fine, errors from here on are not usage errors
+			cmd.SilenceUsage = true
+
+			// every issue is fetched before anything is written to Jira
+			issues, err := getIssues(refs, cfg.Github.TokenEnv)
+			if err != nil {
+				return err
+			}
+
+			var plan *jira.Plan
+			if planFile != "" {
+				dryRun = true
+				plan = &jira.Plan{JiraURL: jiraURL}
+			}
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+
+			// an invalid epic fails once, before any issue is cloned
+			var resolvedEpic *jira.Epic
+			if epic != "" {
+				if resolvedEpic, err = jira.ResolveEpic(epic, jira.WithJiraURL(jiraURL),
+					jira.WithTokenEnv(cfg.Jira.TokenEnv)); err != nil {
 					return err
 				}
-				_, err = jira.Clone(issue, jira.WithProject(project), jira.WithDryRun(dryRun))
+			}
+
+			annotator, err := newAnnotator(cfg, jiraURL)
+			if err != nil {
+				return err
+			}
+
+			var users *jira.Users
+			if cfg.Users.Enabled() {
+				var email func(string) (string, error)
+				if cfg.Users.LookupByEmail {
+					email = func(login string) (string, error) {
+						return gh.GetUserEmail(login, gh.WithTokenEnv(cfg.Github.TokenEnv))
+					}
+				}
+				users = jira.NewUsers(cfg.Users.Map, email)
+			}
+
+			fieldCache := jira.NewFieldCache()
+			report := &jira.Report{}
+			clone := func(ghproject string, issue *github.Issue) error {
+				var (
+					pr      *github.PullRequest
+					reviews []*github.PullRequestReview
+				)
+				if issue.IsPullRequest() {
+					var err error
+					pr, err = gh.GetPullRequest(issue.GetNumber(), gh.WithProject(ghproject),
+						gh.WithTokenEnv(cfg.Github.TokenEnv))
+					if err == nil {
+						reviews, err = gh.ListReviews(issue.GetNumber(), gh.WithProject(ghproject),
+							gh.WithTokenEnv(cfg.Github.TokenEnv))
+					}
+					if err != nil {
+						report.Add(jira.ReportEntry{Project: ghproject, Number: issue.GetNumber(),
+							Outcome: jira.Failed, Err: err})
+						return err
+					}
+				}
+				var comments []*github.IssueComment
+				if withComments {
+					var err error
+					comments, err = gh.ListComments(issue.GetNumber(), gh.WithProject(ghproject),
+						gh.WithTokenEnv(cfg.Github.TokenEnv))
+					if err != nil {
+						report.Add(jira.ReportEntry{Project: ghproject, Number: issue.GetNumber(),
+							Outcome: jira.Failed, Err: err})
+						return err
+					}
+				}
+				t, err := tmpl.Select(cfg.Templates, templateName, ghproject)
 				if err != nil {
+					report.Add(jira.ReportEntry{Project: ghproject, Number: issue.GetNumber(),
+						Outcome: jira.Failed, Err: err})
+					return err
+				}
+				// failures are also recorded in the report
+				_, err = jira.Clone(issue, jira.WithProject(project), jira.WithDryRun(dryRun),
+					jira.WithComments(comments),
+					jira.WithPullRequest(pr, reviews),
+					jira.WithOnExisting(jira.ExistingAction(onExisting)),
+					jira.WithJiraURL(jiraURL),
+					jira.WithIssueType(cfg.Jira.IssueType),
+					jira.WithRules(cfg.Rules),
+					jira.WithFields(cfg.Jira.Fields),
+					jira.WithFieldCache(fieldCache),
+					jira.WithTemplate(t.Summary, t.Description),
+					jira.WithEpic(resolvedEpic),
+					jira.WithTokenEnv(cfg.Jira.TokenEnv),
+					jira.WithUsers(users),
+					jira.WithReporter(cfg.Users.SetReporter),
+					jira.WithPlan(plan),
+					jira.WithLinks(store),
+					jira.WithRemoteLink(cfg.Jira.RemoteLink),
+					jira.WithAnnotator(annotator),
+					jira.WithAttachments(attachments),
+					jira.WithMaxAttachmentSize(maxAttachmentSize(cfg)),
+					jira.WithGithubTokenEnv(cfg.Github.TokenEnv),
+					jira.WithReport(report))
+				// --on-existing report lists the existing clones, they are
+				// not failures
+				var ace *jira.AlreadyClonedError
+				if errors.As(err, &ace) {
+					fmt.Fprintln(cmd.OutOrStdout(), ace)
 					return nil
 				}
+				return err
 			}
-			return nil
+
+			// cloneErr is the last error, it only matters if it did not
+			// make it into the report
+			var cloneErr error
+
+			if filtered {
+				expanded, err := gh.ExpandProjects(ghprojects, gh.WithTokenEnv(cfg.Github.TokenEnv))
+				if err != nil {
+					return err
+				}
+				issues, err := gh.ListAllIssues(expanded, parallel,
+					gh.WithMilestone(milestone),
+					gh.WithAssignee(assignee),
+					gh.WithLabel(label),
+					gh.WithTokenEnv(cfg.Github.TokenEnv),
+				)
+				if err != nil {
+					return err
+				}
+				for _, issue := range issues {
+					if issue.IsPullRequest() && !withPRs {
+						// We have a PR, skipping
+						continue
+					}
+					if cloneErr = clone(gh.IssueProject(issue), issue); cloneErr != nil && failFast {
+						break
+					}
+				}
+			} else {
+				for i, issue := range issues {
+					if cloneErr = clone(refs[i].Project, issue); cloneErr != nil && failFast {
+						break
+					}
+				}
+			}
+
+			if filtered || len(report.Entries) > 1 {
+				report.Print(cmd.OutOrStdout())
+			}
+			if planFile != "" {
+				if err := writePlan(cmd, plan); err != nil {
+					return err
+				}
+			}
+			if err := report.Err(); err != nil {
+				return err
+			}
+			return cloneErr
 		},
 	}
 
 	cmd.Flags().BoolVar(&dryRun, "dryrun", false, "display what we would do without cloning")
 	cmd.Flags().StringVar(&project, "project", "OSDK", "Jira project to clone to")
-	cmd.Flags().StringVar(&ghproject, "github-project", "operator-framework/operator-sdk",
-		"Github project to clone from e.g. ORG/REPO")
+	cmd.Flags().StringSliceVar(&ghprojects, "github-project", []string{"operator-framework/operator-sdk"},
+		"Github projects to clone from e.g. ORG/REPO, several can be given and REPO can be a glob e.g. ORG/*")
+	cmd.Flags().BoolVar(&failFast, "fail-fast", false,
+		"stop at the first issue that fails to clone instead of carrying on with the rest")
+	cmd.Flags().IntVar(&parallel, "parallel", gh.DefaultParallel,
+		"how many Github projects to fetch at the same time")
+	cmd.Flags().StringVar(&jiraURL, "jira-url", "https://issues.redhat.com",
+		"base URL of the Jira instance to clone to")
+	cmd.Flags().StringVar(&epic, "epic", "", "key of the Jira epic to attach the cloned issues to")
+	cmd.Flags().BoolVar(&withComments, "with-comments", false,
+		"copy the Github issue comments to the Jira issue")
+	cmd.Flags().BoolVar(&attachments, "with-attachments", false,
+		"upload the screenshots and files attached to the Github issue and copied comments to the Jira issue")
+	cmd.Flags().StringVar(&onExisting, "on-existing", string(jira.ExistingSkip),
+		"what to do when the issue was already cloned: skip, report, or update")
+	cmd.Flags().StringVar(&milestone, "milestone", "",
+		"clone the open issues in this milestone, by title, number, none or *")
+	cmd.Flags().StringVar(&assignee, "assignee", "", "clone the open issues assigned to this username")
+	cmd.Flags().StringSliceVar(&label, "label", nil,
+		"clone the open issues with these labels i.e. --label \"documentation,bug\"")
+	cmd.Flags().BoolVar(&withPRs, "include-prs", false,
+		"clone the open pull requests matching the filters as well as the issues")
+	cmd.Flags().StringVar(&planFile, "plan", "",
+		"write the Jira requests to this file instead of making them, implies --dryrun")
+	cmd.Flags().StringVar(&applyFile, "apply", "",
+		"make the Jira requests of a plan file written by --plan")
+	cmd.MarkFlagsMutuallyExclusive("apply", "plan")
+	cmd.MarkFlagsMutuallyExclusive("apply", "dryrun")
+	cmd.Flags().BoolVar(&annotateGH, "annotate-github", false,
+		"comment on and label the Github issues cloned to new Jira issues, as set in the github annotate config")
+	cmd.Flags().StringVar(&templateName, "template", "",
+		"name of the config file template rendering the summary and description, "+
+			"instead of the first one matching the Github project")
 
 	return cmd
 }
+
+// writePlan writes the plan of a dry run to the --plan file.
+func writePlan(cmd *cobra.Command, plan *jira.Plan) error {
+	f, err := os.Create(planFile)
+	if err != nil {
+		return err
+	}
+	if err := plan.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "\nWrote the plan for %d issues to %s, review it then run: gh2jira clone --apply %s\n",
+		len(plan.Issues), planFile, planFile)
+	return nil
+}
+
+// applyPlan makes the Jira requests of the --apply plan file against the
+// Jira instance it was made for.
+func applyPlan(cmd *cobra.Command, cfg *config.Config) error {
+	f, err := os.Open(applyFile)
+	if err != nil {
+		return err
+	}
+	plan, err := jira.ReadPlan(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+	if err != nil {
+		return err
+	}
+
+	annotator, err := newAnnotator(cfg, plan.JiraURL)
+	if err != nil {
+		return err
+	}
+
+	report := &jira.Report{}
+	for _, pi := range plan.Issues {
+		_, err := jira.Apply(pi, jira.WithJiraURL(plan.JiraURL),
+			jira.WithTokenEnv(cfg.Jira.TokenEnv),
+			jira.WithLinks(store),
+			jira.WithAnnotator(annotator),
+			jira.WithMaxAttachmentSize(maxAttachmentSize(cfg)),
+			jira.WithGithubTokenEnv(cfg.Github.TokenEnv),
+			jira.WithReport(report))
+		if err != nil && failFast {
+			break
+		}
+	}
+	report.Print(cmd.OutOrStdout())
+	return report.Err()
+}
+
+// maxAttachmentSize returns the largest attachment mirrored, in bytes.
+func maxAttachmentSize(cfg *config.Config) int64 {
+	return int64(cfg.Jira.MaxAttachmentSize) << 20
+}
+
+// newAnnotator returns the annotator writing back to the cloned Github issues
+// when --annotate-github is given, nil otherwise.
+func newAnnotator(cfg *config.Config, jiraURL string) (jira.Annotator, error) {
+	if !annotateGH {
+		return nil, nil
+	}
+	return annotate.New(
+		annotate.WithComment(cfg.Github.Annotate.Comment),
+		annotate.WithLabel(cfg.Github.Annotate.Label),
+		annotate.WithJiraURL(jiraURL),
+		annotate.WithGithubOptions(gh.WithTokenEnv(cfg.Github.TokenEnv)),
+	)
+}
+
+// parseIssueRefs parses the issue arguments, a bare issue number belongs to
+// the only Github project given. Every invalid argument is reported at once.
+func parseIssueRefs(args []string, ghprojects []string) ([]gh.IssueRef, error) {
+	var (
+		refs []gh.IssueRef
+		errs []string
+	)
+	for _, arg := range args {
+		ref, err := gh.ParseIssueRef(arg)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if ref.Project == "" {
+			if len(ghprojects) != 1 || strings.ContainsAny(ghprojects[0], "*?[") {
+				errs = append(errs, fmt.Sprintf("issue %s could be in any of %s, use ORG/REPO#%d",
+					arg, strings.Join(ghprojects, ", "), ref.Number))
+				continue
+			}
+			ref.Project = ghprojects[0]
+		}
+		refs = append(refs, ref)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("nothing was cloned:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return refs, nil
+}
+
+// getIssues fetches the issues refs point at, failing with every one that
+// could not be fetched.
+func getIssues(refs []gh.IssueRef, tokenEnv string) ([]*github.Issue, error) {
+	var (
+		issues []*github.Issue
+		errs   []string
+	)
+	for _, ref := range refs {
+		issue, err := gh.GetIssue(ref.Number, gh.WithProject(ref.Project), gh.WithTokenEnv(tokenEnv))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("nothing was cloned:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return issues, nil
+}
@@ -0,0 +1,82 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clone
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+var _ = Describe("clone", func() {
+	It("should not write to Jira when an issue can not be fetched", func() {
+		githubClient := mock.NewMockedHTTPClient(
+			mock.WithRequestMatchHandler(
+				mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if strings.HasSuffix(r.URL.Path, "/99999") {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"message": "Not Found"}`))
+						return
+					}
+					w.Write(mock.MustMarshal(github.Issue{Number: github.Int(101)}))
+				}),
+			),
+		)
+		var jiraRequests []string
+		transport := http.DefaultTransport
+		defer func() {
+			http.DefaultTransport = transport
+		}()
+		http.DefaultTransport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Host != "api.github.com" {
+				jiraRequests = append(jiraRequests, r.Method+" "+r.URL.String())
+			}
+			return githubClient.Transport.RoundTrip(r)
+		})
+		Expect(os.Setenv("GH2JIRA_TEST_TOKEN", "token")).To(Succeed())
+		defer os.Unsetenv("GH2JIRA_TEST_TOKEN")
+
+		cfg := config.Defaults()
+		cfg.Github.TokenEnv = "GH2JIRA_TEST_TOKEN"
+		cfg.Jira.TokenEnv = "GH2JIRA_TEST_TOKEN"
+		cfg.Links.Path = GinkgoT().TempDir() + "/links.json"
+		cmd := NewCmd()
+		cmd.SetArgs([]string{"--github-project", "foo/bar", "--jira-url", "https://jira.example.com",
+			"101", "102", "99999"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		err := cmd.ExecuteContext(config.NewContext(context.Background(), cfg))
+
+		Expect(err).To(MatchError(ContainSubstring("nothing was cloned:\n  foo/bar#99999: ")))
+		Expect(err).To(MatchError(ContainSubstring("404")))
+		Expect(jiraRequests).To(BeEmpty())
+	})
+})
@@ -0,0 +1,142 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+)
+
+var (
+	output      string
+	force       bool
+	interactive bool
+)
+
+func NewCmd() *cobra.Command {
+	defaults := config.Defaults()
+	cfg := config.Defaults()
+
+	cmd := &cobra.Command{
+		Use:   "genconfig",
+		Short: "Generate a gh2jira config file",
+		Long: "Generate an annotated gh2jira config file from the given flags, " +
+			"or prompt for each value with --interactive",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interactive {
+				if err := prompt(cmd.InOrStdin(), cmd.OutOrStdout(), cfg); err != nil {
+					return err
+				}
+			}
+
+			if output == "-" {
+				return config.Write(cmd.OutOrStdout(), cfg)
+			}
+
+			path := output
+			if path == "" {
+				var err error
+				if path, err = config.DefaultPath(); err != nil {
+					return err
+				}
+			}
+
+			flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+			if force {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, flags, 0o644)
+			if err != nil {
+				if os.IsExist(err) {
+					return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+				}
+				return err
+			}
+			defer f.Close()
+
+			if err := config.Write(f, cfg); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote config to %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "",
+		"file to write, - for stdout (default is $HOME/.config/gh2jira/config.yaml)")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite the config file if it exists")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"prompt for each value, using the flags as defaults")
+
+	cmd.Flags().StringVar(&cfg.Github.Project, "github-project", defaults.Github.Project,
+		"Github project to list and clone from e.g. ORG/REPO")
+	cmd.Flags().StringVar(&cfg.Github.TokenEnv, "github-token-env", defaults.Github.TokenEnv,
+		"environment variable holding the Github token")
+	cmd.Flags().StringVar(&cfg.Jira.URL, "jira-url", defaults.Jira.URL,
+		"base URL of the Jira instance")
+	cmd.Flags().StringVar(&cfg.Jira.Project, "jira-project", defaults.Jira.Project,
+		"Jira project to clone to")
+	cmd.Flags().StringVar(&cfg.Jira.IssueType, "jira-issue-type", defaults.Jira.IssueType,
+		"issue type of the cloned Jira issues")
+	cmd.Flags().StringVar(&cfg.Jira.TokenEnv, "jira-token-env", defaults.Jira.TokenEnv,
+		"environment variable holding the Jira token")
+
+	return cmd
+}
+
+// prompt asks for every config value on out, reading the answers from in. An
+// empty answer keeps the current value.
+func prompt(in io.Reader, out io.Writer, cfg *config.Config) error {
+	questions := []struct {
+		text  string
+		value *string
+	}{
+		{"Github project (ORG/REPO)", &cfg.Github.Project},
+		{"Github token environment variable", &cfg.Github.TokenEnv},
+		{"Jira URL", &cfg.Jira.URL},
+		{"Jira project", &cfg.Jira.Project},
+		{"Jira issue type", &cfg.Jira.IssueType},
+		{"Jira token environment variable", &cfg.Jira.TokenEnv},
+	}
+
+	reader := bufio.NewReader(in)
+	for _, q := range questions {
+		fmt.Fprintf(out, "%s [%s]: ", q.text, *q.value)
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			*q.value = answer
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			break
+		}
+	}
+	return nil
+}
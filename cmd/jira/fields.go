@@ -0,0 +1,118 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/jira"
+)
+
+// maxAllowed is how many allowed values the table shows per field.
+const maxAllowed = 8
+
+var (
+	project   string
+	issueType string
+	jiraURL   string
+	output    string
+)
+
+func newFieldsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fields",
+		Short: "List the fields of the Jira create screen",
+		Long: "List the fields Jira accepts when creating an issue of the given type in the project, " +
+			"with their type and allowed values. Use the name or ID in the jira.fields section " +
+			"of the config file to set them on cloned issues",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("project") {
+				project = cfg.Jira.Project
+			}
+			if !cmd.Flags().Changed("issue-type") {
+				issueType = cfg.Jira.IssueType
+			}
+			if !cmd.Flags().Changed("jira-url") {
+				jiraURL = cfg.Jira.URL
+			}
+
+			fields, err := jira.CreateFields(jira.WithProject(project),
+				jira.WithIssueType(issueType),
+				jira.WithJiraURL(jiraURL),
+				jira.WithTokenEnv(cfg.Jira.TokenEnv))
+			if err != nil {
+				return err
+			}
+
+			switch output {
+			case "table":
+				return printFields(cmd.OutOrStdout(), fields)
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(fields)
+			case "yaml":
+				enc := yaml.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent(2)
+				if err := enc.Encode(fields); err != nil {
+					return err
+				}
+				return enc.Close()
+			}
+			return fmt.Errorf("unknown output format %q, must be one of table, json, yaml", output)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "OSDK", "Jira project to look at")
+	cmd.Flags().StringVar(&issueType, "issue-type", "Story", "issue type to list the fields of")
+	cmd.Flags().StringVar(&jiraURL, "jira-url", "https://issues.redhat.com",
+		"base URL of the Jira instance")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format, one of: table, json, yaml")
+
+	return cmd
+}
+
+func printFields(w io.Writer, fields []jira.FieldInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tID\tTYPE\tREQUIRED\tALLOWED VALUES")
+	for _, f := range fields {
+		required := ""
+		if f.Required {
+			required = "yes"
+			if f.HasDefault {
+				required = "default"
+			}
+		}
+		allowed := f.AllowedValues
+		more := ""
+		if len(allowed) > maxAllowed {
+			more = fmt.Sprintf(", ... %d more", len(allowed)-maxAllowed)
+			allowed = allowed[:maxAllowed]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s%s\n", f.Name, f.ID, f.TypeName(), required,
+			strings.Join(allowed, ", "), more)
+	}
+	return tw.Flush()
+}
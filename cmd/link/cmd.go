@@ -0,0 +1,117 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+// keyRE matches a Jira issue key, e.g. OSDK-1234.
+var keyRE = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-[0-9]+$`)
+
+var ghproject string
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "link ISSUE_ID|ORG/REPO#ISSUE_ID|ISSUE_URL JIRA_KEY",
+		Short: "Record that a Github issue and a Jira issue belong together",
+		Long: "Record a link between an existing Github issue and an existing Jira issue in the " +
+			"link store, as if one had been cloned from the other. Nothing is written to Github " +
+			"or Jira. The sync command keeps linked issues in step",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("github-project") {
+				ghproject = cfg.Github.Project
+			}
+
+			github, err := parseGithub(args[0])
+			if err != nil {
+				return err
+			}
+			key, err := parseKey(args[1])
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+			l := links.Link{Github: github, Jira: key}
+			added, err := store.Add(l)
+			if err != nil {
+				return err
+			}
+			if !added {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is already linked\n", l)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Linked %s\n", l)
+			return nil
+		},
+	}
+
+	addGithubProjectFlag(cmd)
+
+	return cmd
+}
+
+func addGithubProjectFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&ghproject, "github-project", "operator-framework/operator-sdk",
+		"Github project of issues given by number, ORG/REPO")
+}
+
+// parseGithub returns the Github issue in the ORG/REPO#NUMBER form of the link
+// store, issues given by number are in the --github-project.
+func parseGithub(arg string) (string, error) {
+	ref, err := gh.ParseIssueRef(arg)
+	if err != nil {
+		return "", err
+	}
+	if ref.Project == "" {
+		ref.Project = ghproject
+	}
+	return links.GithubRef(ref.Project, ref.Number), nil
+}
+
+func parseKey(arg string) (string, error) {
+	if !keyRE.MatchString(arg) {
+		return "", fmt.Errorf("invalid Jira key %q, expected PROJECT-NUMBER", arg)
+	}
+	return strings.ToUpper(arg), nil
+}
+
+// parseRef returns the Github issue or the Jira key given in arg, the other
+// is empty.
+func parseRef(arg string) (string, string, error) {
+	if keyRE.MatchString(arg) {
+		return "", strings.ToUpper(arg), nil
+	}
+	github, err := parseGithub(arg)
+	if err != nil {
+		return "", "", fmt.Errorf("%q is neither a Github issue nor a Jira key", arg)
+	}
+	return github, "", nil
+}
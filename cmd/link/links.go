@@ -0,0 +1,104 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+var output string
+
+func NewLinksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "links [ISSUE_ID | ORG/REPO#ISSUE_ID | ISSUE_URL | JIRA_KEY ...]",
+		Short: "List the links between Github issues and Jira issues",
+		Long: "List the links recorded in the link store by clone, to-github and link, all of them " +
+			"or those of the given Github issues and Jira keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("github-project") {
+				ghproject = cfg.Github.Project
+			}
+
+			type ref struct{ github, key string }
+			refs := []ref{{}}
+			if len(args) > 0 {
+				refs = refs[:0]
+				for _, arg := range args {
+					github, key, err := parseRef(arg)
+					if err != nil {
+						return err
+					}
+					refs = append(refs, ref{github, key})
+				}
+			}
+			cmd.SilenceUsage = true
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+			found := []links.Link{}
+			for _, r := range refs {
+				ls, err := store.Find(r.github, r.key)
+				if err != nil {
+					return err
+				}
+				found = append(found, ls...)
+			}
+
+			switch output {
+			case "table":
+				return printLinks(cmd.OutOrStdout(), found)
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(found)
+			case "yaml":
+				enc := yaml.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent(2)
+				if err := enc.Encode(found); err != nil {
+					return err
+				}
+				return enc.Close()
+			}
+			return fmt.Errorf("unknown output format %q, must be one of table, json, yaml", output)
+		},
+	}
+
+	addGithubProjectFlag(cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format, one of: table, json, yaml")
+
+	return cmd
+}
+
+func printLinks(w io.Writer, ls []links.Link) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GITHUB\tJIRA\tCREATED")
+	for _, l := range ls {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", l.Github, l.Jira, l.Created.Local().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
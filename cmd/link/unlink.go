@@ -0,0 +1,78 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+func NewUnlinkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlink ISSUE_ID|ORG/REPO#ISSUE_ID|ISSUE_URL|JIRA_KEY [JIRA_KEY]",
+		Short: "Remove links from the link store",
+		Long: "Remove every link of the given Github issue or Jira key from the link store, or only " +
+			"the link between the Github issue and the Jira key when both are given. Nothing is " +
+			"written to Github or Jira",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("github-project") {
+				ghproject = cfg.Github.Project
+			}
+
+			var (
+				github, key string
+				err         error
+			)
+			if len(args) == 2 {
+				if github, err = parseGithub(args[0]); err != nil {
+					return err
+				}
+				if key, err = parseKey(args[1]); err != nil {
+					return err
+				}
+			} else if github, key, err = parseRef(args[0]); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+			removed, err := store.Remove(github, key)
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				return fmt.Errorf("no link found for %s", strings.Join(args, " and "))
+			}
+			for _, l := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "Unlinked %s\n", l)
+			}
+			return nil
+		},
+	}
+
+	addGithubProjectFlag(cmd)
+
+	return cmd
+}
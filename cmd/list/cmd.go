@@ -15,52 +15,139 @@
 package list
 
 import (
+	"strings"
+
+	"github.com/google/go-github/v47/github"
 	"github.com/spf13/cobra"
 
+	"github.com/jmrodri/gh2jira/internal/config"
 	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/links"
 )
 
 var (
-	milestone string
-	assignee  string
-	project   string
-	label     []string
+	milestone  string
+	assignee   string
+	projects   []string
+	parallel   int
+	label      []string
+	exclude    []string
+	state      string
+	creator    string
+	mentioned  string
+	since      string
+	sortBy     string
+	direction  string
+	search     string
+	output     string
+	noColor    bool
+	withPRs    bool
+	hideCloned bool
 )
 
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List Github issues",
-		Long:  "List Github issues filtered by milestone, assignee, or label",
+		Long: "List Github issues filtered by milestone, assignee, label, state, " +
+			"creator, mention or date, or matching a Github search query",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			issues, err := gh.ListIssues(gh.WithMilestone(milestone),
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("project") {
+				projects = []string{cfg.Github.Project}
+			}
+
+			printer, err := gh.NewPrinter(output, !noColor)
+			if err != nil {
+				return err
+			}
+
+			expanded, err := gh.ExpandProjects(projects, gh.WithTokenEnv(cfg.Github.TokenEnv))
+			if err != nil {
+				return err
+			}
+
+			issues, err := gh.ListAllIssues(expanded, parallel,
+				gh.WithMilestone(milestone),
 				gh.WithAssignee(assignee),
-				gh.WithProject(project),
 				gh.WithLabel(label),
+				gh.WithExcludeLabel(exclude),
+				gh.WithState(state),
+				gh.WithCreator(creator),
+				gh.WithMentioned(mentioned),
+				gh.WithSince(since),
+				gh.WithSort(sortBy),
+				gh.WithDirection(direction),
+				gh.WithSearch(search),
+				gh.WithTokenEnv(cfg.Github.TokenEnv),
 			)
 			if err != nil {
 				return err
 			}
 
+			cloned := map[string]bool{}
+			if hideCloned {
+				store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+				if err != nil {
+					return err
+				}
+				all, err := store.Find("", "")
+				if err != nil {
+					return err
+				}
+				for _, l := range all {
+					cloned[strings.ToLower(l.Github)] = true
+				}
+			}
+
 			// print the issues
+			var filtered []*github.Issue
 			for _, issue := range issues {
-				if issue.IsPullRequest() {
+				if issue.IsPullRequest() && !withPRs {
 					// We have a PR, skipping
 					continue
 				}
-				gh.PrintGithubIssue(issue, true, true)
+				ref := links.GithubRef(gh.IssueProject(issue), issue.GetNumber())
+				if cloned[strings.ToLower(ref)] {
+					continue
+				}
+				filtered = append(filtered, issue)
 			}
-			return nil
+			return printer.Print(cmd.OutOrStdout(), filtered)
 		},
 	}
 
 	cmd.Flags().StringVar(&milestone, "milestone", "",
-		"the milestone ID from the url, not the display name")
+		"milestone title, or part of it, the number from the url, none or *")
 	cmd.Flags().StringVar(&assignee, "assignee", "", "username of the issue is assigned")
-	cmd.Flags().StringVar(&project, "project", "operator-framework/operator-sdk",
-		"Github project to list e.g. ORG/REPO")
+	cmd.Flags().StringSliceVar(&projects, "project", []string{"operator-framework/operator-sdk"},
+		"Github projects to list e.g. ORG/REPO, several can be given and REPO can be a glob e.g. ORG/*")
+	cmd.Flags().IntVar(&parallel, "parallel", gh.DefaultParallel,
+		"how many projects to fetch at the same time")
 	cmd.Flags().StringSliceVar(&label, "label", nil,
 		"label i.e. --label \"documentation,bug\" or --label doc --label bug")
+	cmd.Flags().StringSliceVar(&exclude, "exclude-label", nil,
+		"hide issues with any of these labels i.e. --exclude-label \"wontfix,duplicate\"")
+	cmd.Flags().StringVar(&state, "state", "open", "issue state, one of: open, closed, all")
+	cmd.Flags().StringVar(&creator, "creator", "", "username of the issue author")
+	cmd.Flags().StringVar(&mentioned, "mentioned", "", "username mentioned in the issue")
+	cmd.Flags().StringVar(&since, "since", "",
+		"only issues updated since this date, i.e. 2022-06-30 or 2022-06-30T15:04:05Z")
+	cmd.Flags().StringVar(&sortBy, "sort", "", "sort by one of: created, updated, comments")
+	cmd.Flags().StringVar(&direction, "direction", "", "sort direction, asc or desc")
+	cmd.Flags().StringVar(&search, "search", "",
+		"Github search query i.e. \"is:open label:bug no:assignee\", limited to --project unless it has a repo:, org: or user: qualifier")
+	cmd.Flags().StringVarP(&output, "output", "o", "oneline",
+		"output format, one of: "+strings.Join(gh.Formats, ", "))
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "do not color the oneline output")
+	cmd.Flags().BoolVar(&withPRs, "include-prs", false, "list pull requests as well as issues")
+	cmd.Flags().BoolVar(&hideCloned, "hide-cloned", false,
+		"hide issues the link store has a Jira issue for")
+
+	// the search query replaces the list filters, sort and direction still apply
+	for _, f := range []string{"milestone", "assignee", "label", "state", "creator", "mentioned", "since"} {
+		cmd.MarkFlagsMutuallyExclusive("search", f)
+	}
 
 	return cmd
 }
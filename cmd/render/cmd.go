@@ -0,0 +1,120 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+)
+
+var (
+	ghproject    string
+	templateName string
+	summary      string
+	description  string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render ISSUE_ID | ORG/REPO#ISSUE_ID | ISSUE_URL",
+		Short: "Preview the Jira summary and description of a Github issue",
+		Long: "Render the summary and description clone would give the Jira issue cloned from the " +
+			"given Github issue or pull request, using the config file templates or the --summary " +
+			"and --description templates. Nothing is written to Jira",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("github-project") {
+				ghproject = cfg.Github.Project
+			}
+
+			ref, err := gh.ParseIssueRef(args[0])
+			if err != nil {
+				return err
+			}
+			if ref.Project == "" {
+				ref.Project = ghproject
+			}
+
+			t, err := tmpl.Select(cfg.Templates, templateName, ref.Project)
+			if err != nil {
+				return err
+			}
+			if summary != "" {
+				t.Summary = summary
+			}
+			if description != "" {
+				t.Description = description
+			}
+			cmd.SilenceUsage = true
+
+			issue, err := gh.GetIssue(ref.Number, gh.WithProject(ref.Project),
+				gh.WithTokenEnv(cfg.Github.TokenEnv))
+			if err != nil {
+				return err
+			}
+			var (
+				pr      *github.PullRequest
+				reviews []*github.PullRequestReview
+			)
+			if issue.IsPullRequest() {
+				pr, err = gh.GetPullRequest(ref.Number, gh.WithProject(ref.Project),
+					gh.WithTokenEnv(cfg.Github.TokenEnv))
+				if err != nil {
+					return err
+				}
+				reviews, err = gh.ListReviews(ref.Number, gh.WithProject(ref.Project),
+					gh.WithTokenEnv(cfg.Github.TokenEnv))
+				if err != nil {
+					return err
+				}
+			}
+
+			s, d, err := jira.Render(issue, jira.WithTemplate(t.Summary, t.Description),
+				jira.WithPullRequest(pr, reviews))
+			if err != nil {
+				return err
+			}
+
+			name := t.Name
+			if name == "" {
+				name = "built-in"
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Template: %s\n", name)
+			fmt.Fprintf(out, "Summary: %s\n", s)
+			fmt.Fprintln(out, "Description:")
+			fmt.Fprint(out, d)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&ghproject, "github-project", "operator-framework/operator-sdk",
+		"Github project of a bare issue number e.g. ORG/REPO")
+	cmd.Flags().StringVar(&templateName, "template", "",
+		"name of the config file template to render, instead of the first one matching the Github project")
+	cmd.Flags().StringVar(&summary, "summary", "", "summary template to render instead of the configured one")
+	cmd.Flags().StringVar(&description, "description", "",
+		"description template to render instead of the configured one")
+
+	return cmd
+}
@@ -18,17 +18,56 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/jmrodri/gh2jira/cmd/clone"
+	"github.com/jmrodri/gh2jira/cmd/genconfig"
+	"github.com/jmrodri/gh2jira/cmd/jira"
+	"github.com/jmrodri/gh2jira/cmd/link"
 	"github.com/jmrodri/gh2jira/cmd/list"
+	"github.com/jmrodri/gh2jira/cmd/render"
+	"github.com/jmrodri/gh2jira/cmd/sync"
+	"github.com/jmrodri/gh2jira/cmd/togithub"
+	"github.com/jmrodri/gh2jira/internal/config"
 )
 
+var configFile string
+
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "gh2jira",
 		Short: "github to jira issue cloner",
 		Long:  "",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if skipConfig(cmd) {
+				return nil
+			}
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				return err
+			}
+			cmd.SetContext(config.NewContext(cmd.Context(), cfg))
+			return nil
+		},
 	}
-	// add the child commands: list and clone
-	cmd.AddCommand(list.NewCmd(), clone.NewCmd())
+
+	cmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"config file (default is $HOME/.config/gh2jira/config.yaml)")
+
+	// add the child commands: list, clone, render, sync, to-github, link,
+	// unlink, links, jira and genconfig
+	cmd.AddCommand(list.NewCmd(), clone.NewCmd(), render.NewCmd(), sync.NewCmd(),
+		togithub.NewCmd(), link.NewCmd(), link.NewUnlinkCmd(), link.NewLinksCmd(), jira.NewCmd(),
+		genconfig.NewCmd())
 
 	return cmd
 }
+
+// skipConfig tells whether cmd works without loading the config file, so
+// genconfig can overwrite a broken one and completion still works.
+func skipConfig(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "genconfig", "completion", "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+)
+
+var _ = Describe("gh2jira", func() {
+	var path string
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte("jira: [not, a, map\n"), 0o644)).To(Succeed())
+	})
+	AfterEach(func() {
+		configFile = ""
+	})
+
+	run := func(args ...string) error {
+		cmd := NewCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	It("should fail on an invalid config file", func() {
+		Expect(run("--config", path, "links")).NotTo(Succeed())
+	})
+	It("should overwrite an invalid config file with genconfig --force", func() {
+		Expect(run("--config", path, "genconfig", "--force", "--output", path)).To(Succeed())
+		_, err := config.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("should complete without an invalid config file getting in the way", func() {
+		Expect(run("--config", path, "completion", "bash")).To(Succeed())
+	})
+})
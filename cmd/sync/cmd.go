@@ -0,0 +1,82 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/syncer"
+)
+
+var (
+	dryRun    bool
+	project   string
+	jiraURL   string
+	direction string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync the state of Github issues and their Jira clones",
+		Long: "Close or reopen Github issues and transition their Jira clones so both sides agree. " +
+			"WARNING! This will write to Github and Jira. Use --dryrun to see what will happen",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("project") {
+				project = cfg.Jira.Project
+			}
+			if !cmd.Flags().Changed("jira-url") {
+				jiraURL = cfg.Jira.URL
+			}
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+
+			_, err = syncer.Sync(
+				syncer.WithDryRun(dryRun),
+				syncer.WithDirection(syncer.Direction(direction)),
+				syncer.WithMapping(syncer.Mapping{
+					ClosedStatuses: cfg.Sync.ClosedStatuses,
+					CloseStatus:    cfg.Sync.CloseStatus,
+					ReopenStatus:   cfg.Sync.ReopenStatus,
+				}),
+				syncer.WithLinks(store),
+				syncer.WithGithubOptions(gh.WithTokenEnv(cfg.Github.TokenEnv)),
+				syncer.WithJiraOptions(jira.WithProject(project),
+					jira.WithJiraURL(jiraURL),
+					jira.WithTokenEnv(cfg.Jira.TokenEnv),
+					jira.WithRemoteLink(cfg.Jira.RemoteLink)),
+			)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dryrun", false, "display the planned changes without making them")
+	cmd.Flags().StringVar(&project, "project", "OSDK", "Jira project to sync")
+	cmd.Flags().StringVar(&jiraURL, "jira-url", "https://issues.redhat.com",
+		"base URL of the Jira instance")
+	cmd.Flags().StringVar(&direction, "direction", string(syncer.FromGithub),
+		"which side wins when they disagree: github, jira, or both (most recently updated, comments included)")
+
+	return cmd
+}
@@ -0,0 +1,92 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package togithub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/jira2gh"
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+var (
+	dryRun    bool
+	ghproject string
+	jiraURL   string
+	label     []string
+	milestone string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "to-github JIRA_KEY ...",
+		Short: "Clone given Jira issues to Github",
+		Long: "Create a Github issue from each given Jira issue and link the Jira issue back to it. " +
+			"The description is converted to Markdown and the config rules map the issue type, " +
+			"priority, labels and components back to Github labels. " +
+			"WARNING! This will write to Github and Jira. Use --dryrun to see what will happen",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if !cmd.Flags().Changed("github-project") {
+				ghproject = cfg.Github.Project
+			}
+			if !cmd.Flags().Changed("jira-url") {
+				jiraURL = cfg.Jira.URL
+			}
+			cmd.SilenceUsage = true
+
+			store, err := links.Open(cfg.Links.Backend, cfg.Links.Path)
+			if err != nil {
+				return err
+			}
+
+			for _, key := range args {
+				_, err := jira2gh.Clone(key,
+					jira2gh.WithDryRun(dryRun),
+					jira2gh.WithRules(cfg.Rules),
+					jira2gh.WithLabels(label),
+					jira2gh.WithMilestone(milestone),
+					jira2gh.WithJiraURL(jiraURL),
+					jira2gh.WithLinks(store),
+					jira2gh.WithGithubOptions(gh.WithProject(ghproject),
+						gh.WithTokenEnv(cfg.Github.TokenEnv)),
+					jira2gh.WithJiraOptions(jira.WithTokenEnv(cfg.Jira.TokenEnv),
+						jira.WithRemoteLink(cfg.Jira.RemoteLink)),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dryrun", false, "display what we would do without cloning")
+	cmd.Flags().StringVar(&ghproject, "github-project", "operator-framework/operator-sdk",
+		"Github project to create the issues in, ORG/REPO")
+	cmd.Flags().StringVar(&jiraURL, "jira-url", "https://issues.redhat.com",
+		"base URL of the Jira instance")
+	cmd.Flags().StringSliceVar(&label, "label", nil,
+		"extra labels to add to the Github issues, comma separated or repeated")
+	cmd.Flags().StringVar(&milestone, "milestone", "",
+		"Github milestone title or number, defaults to the first Jira fix version that is a "+
+			"milestone, none for no milestone")
+
+	return cmd
+}
@@ -0,0 +1,159 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package annotate writes back to the Github issues cloned to Jira, with a
+// comment naming the Jira issue and a label that list can filter on.
+package annotate
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+)
+
+// Data is what the comment template is executed on.
+type Data struct {
+	// Key of the Jira issue, e.g. OSDK-1234.
+	Key string
+	// URL of the Jira issue.
+	URL string
+	// Project is the ORG/REPO of the Github issue.
+	Project string
+	// Number of the Github issue.
+	Number int
+}
+
+type Option func(*Annotator) error
+
+// Annotator comments on and labels the Github issues cloned to Jira. It
+// satisfies jira.Annotator.
+type Annotator struct {
+	comment    *template.Template
+	label      string
+	jiraURL    string
+	githubOpts []gh.Option
+}
+
+// WithComment sets the Go template of the comment, executed on Data. An
+// empty comment posts none.
+func WithComment(text string) Option {
+	return func(a *Annotator) error {
+		if text == "" {
+			a.comment = nil
+			return nil
+		}
+		t, err := tmpl.Parse("comment", text)
+		if err != nil {
+			return fmt.Errorf("invalid comment template: %w", err)
+		}
+		a.comment = t
+		return nil
+	}
+}
+
+// WithLabel sets the label added to the Github issue, empty for none.
+func WithLabel(l string) Option {
+	return func(a *Annotator) error {
+		a.label = l
+		return nil
+	}
+}
+
+// WithJiraURL sets the Jira instance the issues are cloned to, for the URL
+// in the comment.
+func WithJiraURL(j string) Option {
+	return func(a *Annotator) error {
+		a.jiraURL = j
+		return nil
+	}
+}
+
+// WithGithubOptions sets the options of the Github requests.
+func WithGithubOptions(opts ...gh.Option) Option {
+	return func(a *Annotator) error {
+		a.githubOpts = append(a.githubOpts, opts...)
+		return nil
+	}
+}
+
+// New returns an Annotator, or an error if it has neither a comment nor a
+// label to write.
+func New(opts ...Option) (*Annotator, error) {
+	a := &Annotator{}
+	for _, opt := range opts {
+		if err := opt(a); err != nil {
+			return nil, err
+		}
+	}
+	if a.comment == nil && a.label == "" {
+		return nil, fmt.Errorf("nothing to annotate the Github issues with: set a comment or a label")
+	}
+	if a.jiraURL == "" {
+		a.jiraURL = "https://issues.redhat.com"
+	}
+	return a, nil
+}
+
+// Describe returns what Annotate writes on the Github issue, for dry runs.
+func (a *Annotator) Describe(project string, number int) string {
+	var parts []string
+	if a.comment != nil {
+		parts = append(parts, "comment")
+	}
+	if a.label != "" {
+		parts = append(parts, fmt.Sprintf("label %q", a.label))
+	}
+	return fmt.Sprintf("add %s to %s#%d", strings.Join(parts, " and "), project, number)
+}
+
+// Annotate posts the comment on the Github issue number of the project and
+// adds the label to it.
+func (a *Annotator) Annotate(project string, number int, key string) error {
+	opts := append(append([]gh.Option{}, a.githubOpts...), gh.WithProject(project))
+
+	if a.comment != nil {
+		body, err := a.render(project, number, key)
+		if err != nil {
+			return err
+		}
+		if _, err := gh.AddComment(number, body, opts...); err != nil {
+			return fmt.Errorf("unable to comment on %s#%d: %w", project, number, err)
+		}
+	}
+	if a.label != "" {
+		if err := gh.AddLabels(number, []string{a.label}, opts...); err != nil {
+			return fmt.Errorf("unable to label %s#%d: %w", project, number, err)
+		}
+	}
+	return nil
+}
+
+// render executes the comment template for the Jira issue key.
+func (a *Annotator) render(project string, number int, key string) (string, error) {
+	var b strings.Builder
+	err := a.comment.Execute(&b, Data{
+		Key:     key,
+		URL:     jira.BrowseURL(a.jiraURL, key),
+		Project: project,
+		Number:  number,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to render the comment: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotate
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+)
+
+var _ jira.Annotator = &Annotator{}
+
+var _ = Describe("Annotator", func() {
+	Describe("New", func() {
+		It("should need a comment or a label", func() {
+			_, err := New(WithComment(""), WithLabel(""))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should reject an invalid comment template", func() {
+			_, err := New(WithComment("{{ .Key"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid comment template"))
+		})
+	})
+	Describe("Describe", func() {
+		It("should name what is written", func() {
+			a, err := New(WithComment("Tracked as {{ .Key }}"), WithLabel("tracked-in-jira"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Describe("foo/bar", 123)).To(Equal(`add comment and label "tracked-in-jira" to foo/bar#123`))
+
+			a, err = New(WithLabel("tracked-in-jira"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Describe("foo/bar", 123)).To(Equal(`add label "tracked-in-jira" to foo/bar#123`))
+		})
+	})
+	Describe("Annotate", func() {
+		It("should comment on and label the Github issue", func() {
+			var (
+				comment github.IssueComment
+				labels  []string
+				paths   []string
+			)
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						paths = append(paths, r.URL.Path)
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						w.Write(mock.MustMarshal(comment))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesLabelsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						paths = append(paths, r.URL.Path)
+						Expect(json.NewDecoder(r.Body).Decode(&labels)).To(Succeed())
+						w.Write(mock.MustMarshal([]github.Label{}))
+					}),
+				),
+			)
+			a, err := New(
+				WithComment("Tracked downstream as [{{ .Key }}]({{ .URL }}) for {{ .Project }}#{{ .Number }}"),
+				WithLabel("tracked-in-jira"),
+				WithJiraURL("https://issues.example.com/"),
+				WithGithubOptions(gh.WithClient(mockedHTTPClient)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(a.Annotate("foo/bar", 123, "OSDK-7")).To(Succeed())
+			Expect(comment.GetBody()).To(Equal(
+				"Tracked downstream as [OSDK-7](https://issues.example.com/browse/OSDK-7) for foo/bar#123"))
+			Expect(labels).To(Equal([]string{"tracked-in-jira"}))
+			Expect(paths).To(Equal([]string{
+				"/repos/foo/bar/issues/123/comments",
+				"/repos/foo/bar/issues/123/labels",
+			}))
+		})
+		It("should return the Github error", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesLabelsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusForbidden, "Resource not accessible")
+					}),
+				),
+			)
+			a, err := New(WithLabel("tracked-in-jira"), WithGithubOptions(gh.WithClient(mockedHTTPClient)))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = a.Annotate("foo/bar", 123, "OSDK-7")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("unable to label foo/bar#123"))
+		})
+	})
+})
@@ -0,0 +1,253 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/rules"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+)
+
+// Config holds the settings read from the gh2jira config file. Every value
+// becomes the default of the matching command line flag.
+type Config struct {
+	Github GithubConfig `yaml:"github"`
+	Jira   JiraConfig   `yaml:"jira"`
+	Sync   SyncConfig   `yaml:"sync"`
+	Users  UsersConfig  `yaml:"users"`
+	Links  LinksConfig  `yaml:"links"`
+	Rules  []rules.Rule `yaml:"rules"`
+	// Templates render the summary and description of cloned issues.
+	Templates []tmpl.Template `yaml:"templates"`
+}
+
+type GithubConfig struct {
+	Project  string `yaml:"project"`
+	TokenEnv string `yaml:"tokenEnv"`
+	// Annotate is what clone --annotate-github writes back to the Github
+	// issues it clones.
+	Annotate AnnotateConfig `yaml:"annotate"`
+}
+
+// AnnotateConfig sets the comment and label written back to Github issues
+// cloned to new Jira issues. Either may be empty, but not both.
+type AnnotateConfig struct {
+	// Comment is a Go template executed with the Jira .Key and .URL and the
+	// Github .Project and .Number.
+	Comment string `yaml:"comment"`
+	// Label is added to the Github issue, list can filter on it.
+	Label string `yaml:"label"`
+}
+
+type JiraConfig struct {
+	URL       string `yaml:"url"`
+	Project   string `yaml:"project"`
+	IssueType string `yaml:"issueType"`
+	Epic      string `yaml:"epic"`
+	TokenEnv  string `yaml:"tokenEnv"`
+	// Fields sets extra fields, by name or ID, on cloned issues. The values
+	// are Go templates executed on the Github issue.
+	Fields map[string]string `yaml:"fields"`
+	// RemoteLink adds a link to the Github issue to the Links panel of cloned
+	// issues, and keeps its state up to date.
+	RemoteLink bool `yaml:"remoteLink"`
+	// MaxAttachmentSize is the largest Github attachment, in MiB, that
+	// clone --with-attachments mirrors to Jira.
+	MaxAttachmentSize int `yaml:"maxAttachmentSize"`
+}
+
+// UsersConfig maps Github logins to Jira users, for the assignee and
+// reporter of cloned issues.
+type UsersConfig struct {
+	// Map holds the Jira username of each Github login.
+	Map map[string]string `yaml:"map"`
+	// LookupByEmail searches Jira for the public email of Github users that
+	// are not in Map.
+	LookupByEmail bool `yaml:"lookupByEmail"`
+	// SetReporter makes the Github author the Jira reporter, which needs the
+	// Modify Reporter permission.
+	SetReporter bool `yaml:"setReporter"`
+}
+
+// Enabled returns true if Github users are mapped to Jira users at all.
+func (u UsersConfig) Enabled() bool {
+	return len(u.Map) > 0 || u.LookupByEmail
+}
+
+// LinksConfig sets where the links between Github issues and Jira issues are
+// recorded.
+type LinksConfig struct {
+	// Backend is json for a local file, or yaml for a file meant to be
+	// committed to a git repository shared by the team.
+	Backend string `yaml:"backend"`
+	// Path of the link file, empty for the default location of the backend.
+	Path string `yaml:"path"`
+}
+
+// SyncConfig maps Github issue states to Jira statuses for the sync command.
+type SyncConfig struct {
+	ClosedStatuses []string `yaml:"closedStatuses"`
+	CloseStatus    string   `yaml:"closeStatus"`
+	ReopenStatus   string   `yaml:"reopenStatus"`
+}
+
+type contextKey struct{}
+
+// Defaults returns the configuration used when there is no config file.
+func Defaults() *Config {
+	return &Config{
+		Github: GithubConfig{
+			Project:  "operator-framework/operator-sdk",
+			TokenEnv: "GITHUB_TOKEN",
+			Annotate: AnnotateConfig{
+				Comment: "Tracked downstream as [{{ .Key }}]({{ .URL }})",
+				Label:   "tracked-in-jira",
+			},
+		},
+		Jira: JiraConfig{
+			URL:        "https://issues.redhat.com",
+			Project:    "OSDK",
+			IssueType:  "Story",
+			TokenEnv:   "JIRA_TOKEN",
+			RemoteLink: true,
+			// Jira's default attachment size limit
+			MaxAttachmentSize: 10,
+		},
+		Sync: SyncConfig{
+			ClosedStatuses: []string{"Closed", "Done", "Resolved"},
+			CloseStatus:    "Closed",
+			ReopenStatus:   "New",
+		},
+		Links: LinksConfig{
+			Backend: "json",
+		},
+	}
+}
+
+// DefaultPath returns the location of the config file when none is given,
+// typically ~/.config/gh2jira/config.yaml.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh2jira", "config.yaml"), nil
+}
+
+// Load reads the config file at path on top of the defaults. If path is
+// empty the DefaultPath is used, and a missing file there is not an error.
+func Load(path string) (*Config, error) {
+	optional := false
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return Defaults(), nil
+		}
+		optional = true
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return Defaults(), nil
+		}
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes the YAML config in data on top of the defaults. Unknown keys
+// are an error so that typos do not go unnoticed.
+func Parse(data []byte) (*Config, error) {
+	cfg := Defaults()
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	for _, r := range cfg.Rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if !contains(links.Backends, cfg.Links.Backend) {
+		return nil, fmt.Errorf("invalid config: links backend %q must be one of %s",
+			cfg.Links.Backend, strings.Join(links.Backends, ", "))
+	}
+	if cfg.Github.Annotate.Comment == "" && cfg.Github.Annotate.Label == "" {
+		return nil, fmt.Errorf("invalid config: github annotate needs a comment or a label")
+	}
+	if _, err := template.New("comment").Funcs(tmpl.Funcs).Parse(cfg.Github.Annotate.Comment); err != nil {
+		return nil, fmt.Errorf("invalid config: github annotate comment: %w", err)
+	}
+	if cfg.Jira.MaxAttachmentSize <= 0 {
+		return nil, fmt.Errorf("invalid config: jira maxAttachmentSize must be a positive number of MiB")
+	}
+	for name, value := range cfg.Jira.Fields {
+		if _, err := template.New(name).Funcs(tmpl.Funcs).Parse(value); err != nil {
+			return nil, fmt.Errorf("invalid config: field %q: %w", name, err)
+		}
+	}
+	names := map[string]bool{}
+	for _, t := range cfg.Templates {
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		if names[strings.ToLower(t.Name)] {
+			return nil, fmt.Errorf("invalid config: template %q is defined more than once", t.Name)
+		}
+		names[strings.ToLower(t.Name)] = true
+	}
+	return cfg, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContext returns a copy of ctx carrying cfg.
+func NewContext(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, cfg)
+}
+
+// FromContext returns the Config stored in ctx by NewContext, or the defaults
+// if there is none.
+func FromContext(ctx context.Context) *Config {
+	if ctx != nil {
+		if cfg, ok := ctx.Value(contextKey{}).(*Config); ok && cfg != nil {
+			return cfg
+		}
+	}
+	return Defaults()
+}
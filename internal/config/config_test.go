@@ -0,0 +1,257 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/jmrodri/gh2jira/internal/rules"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+
+	Describe("Parse", func() {
+		It("should return the defaults for an empty file", func() {
+			cfg, err := Parse([]byte(""))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg).To(Equal(Defaults()))
+		})
+		It("should override only the given values", func() {
+			cfg, err := Parse([]byte("jira:\n  project: FOO\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Jira.Project).To(Equal("FOO"))
+			Expect(cfg.Jira.URL).To(Equal(Defaults().Jira.URL))
+			Expect(cfg.Github).To(Equal(Defaults().Github))
+		})
+		It("should replace lists rather than append to them", func() {
+			cfg, err := Parse([]byte("sync:\n  closedStatuses: [Verified]\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Sync.ClosedStatuses).To(Equal([]string{"Verified"}))
+			Expect(cfg.Sync.CloseStatus).To(Equal(Defaults().Sync.CloseStatus))
+		})
+		It("should read the label rules", func() {
+			cfg, err := Parse([]byte("rules:\n  - label: kind/bug\n    issueType: Bug\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Rules).To(HaveLen(1))
+			Expect(cfg.Rules[0].IssueType).To(Equal("Bug"))
+		})
+		It("should return an error for an invalid rule", func() {
+			_, err := Parse([]byte("rules:\n  - label: kind/bug\n"))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should read the extra Jira fields", func() {
+			cfg, err := Parse([]byte("jira:\n  fields:\n    Story Points: 3\n" +
+				"    customfield_10001: \"{{ .GetMilestone.GetTitle }}\"\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Jira.Fields).To(Equal(map[string]string{
+				"Story Points":      "3",
+				"customfield_10001": "{{ .GetMilestone.GetTitle }}",
+			}))
+		})
+		It("should return an error for an invalid field template", func() {
+			_, err := Parse([]byte("jira:\n  fields:\n    Team: \"{{ .Team\"\n"))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should read the templates", func() {
+			cfg, err := Parse([]byte("templates:\n  - name: olm\n" +
+				"    project: operator-framework/operator-lifecycle-manager\n" +
+				"    summary: \"[OLM] {{ .GetTitle }}\"\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Templates).To(Equal([]tmpl.Template{{
+				Name:    "olm",
+				Project: "operator-framework/operator-lifecycle-manager",
+				Summary: "[OLM] {{ .GetTitle }}",
+			}}))
+		})
+		It("should return an error for an invalid template", func() {
+			_, err := Parse([]byte("templates:\n  - name: olm\n    summary: \"{{ .GetTitle | nope }}\"\n"))
+			Expect(err).To(MatchError(ContainSubstring(`template "olm"`)))
+		})
+		It("should return an error for templates with the same name", func() {
+			_, err := Parse([]byte("templates:\n  - name: olm\n    summary: a\n" +
+				"  - name: OLM\n    summary: b\n"))
+			Expect(err).To(MatchError(`invalid config: template "OLM" is defined more than once`))
+		})
+		It("should read the user mapping", func() {
+			cfg, err := Parse([]byte("users:\n  map:\n    jmrodri: jesusr\n  lookupByEmail: true\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Users.Map).To(Equal(map[string]string{"jmrodri": "jesusr"}))
+			Expect(cfg.Users.LookupByEmail).To(BeTrue())
+			Expect(cfg.Users.SetReporter).To(BeFalse())
+			Expect(cfg.Users.Enabled()).To(BeTrue())
+			Expect(Defaults().Users.Enabled()).To(BeFalse())
+		})
+		It("should turn off the remote links", func() {
+			Expect(Defaults().Jira.RemoteLink).To(BeTrue())
+			cfg, err := Parse([]byte("jira:\n  remoteLink: false\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Jira.RemoteLink).To(BeFalse())
+		})
+		It("should read the Github annotations", func() {
+			cfg, err := Parse([]byte("github:\n  annotate:\n    comment: \"\"\n    label: jira\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Github.Annotate).To(Equal(AnnotateConfig{Label: "jira"}))
+		})
+		It("should return an error for an invalid Github annotation", func() {
+			_, err := Parse([]byte("github:\n  annotate:\n    comment: \"\"\n    label: \"\"\n"))
+			Expect(err).To(MatchError(ContainSubstring("needs a comment or a label")))
+
+			_, err = Parse([]byte("github:\n  annotate:\n    comment: \"{{ .Key\"\n"))
+			Expect(err).To(MatchError(ContainSubstring("github annotate comment")))
+		})
+		It("should read the attachment size limit", func() {
+			Expect(Defaults().Jira.MaxAttachmentSize).To(Equal(10))
+			cfg, err := Parse([]byte("jira:\n  maxAttachmentSize: 25\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Jira.MaxAttachmentSize).To(Equal(25))
+
+			_, err = Parse([]byte("jira:\n  maxAttachmentSize: 0\n"))
+			Expect(err).To(MatchError(ContainSubstring("maxAttachmentSize")))
+		})
+		It("should read the link store settings", func() {
+			cfg, err := Parse([]byte("links:\n  backend: yaml\n  path: /repo/links.yaml\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Links).To(Equal(LinksConfig{Backend: "yaml", Path: "/repo/links.yaml"}))
+		})
+		It("should return an error for an unknown link store backend", func() {
+			_, err := Parse([]byte("links:\n  backend: sqlite\n"))
+			Expect(err).To(MatchError(ContainSubstring(`links backend "sqlite"`)))
+		})
+		It("should return an error for unknown keys", func() {
+			_, err := Parse([]byte("jira:\n  projcet: FOO\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Load", func() {
+		var dir string
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "gh2jira-config")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+		It("should return an error if the given file is missing", func() {
+			_, err := Load(filepath.Join(dir, "missing.yaml"))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should read the given file", func() {
+			path := filepath.Join(dir, "config.yaml")
+			err := os.WriteFile(path, []byte("github:\n  project: foo/bar\n"), 0o644)
+			Expect(err).NotTo(HaveOccurred())
+
+			cfg, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Github.Project).To(Equal("foo/bar"))
+		})
+	})
+
+	Describe("Write", func() {
+		It("should write a config that parses back to the same values", func() {
+			cfg := Defaults()
+			cfg.Jira.URL = "http://localhost:8080"
+			cfg.Jira.Project = "TEST"
+			cfg.Github.TokenEnv = "GH_PAT"
+			cfg.Jira.RemoteLink = false
+			cfg.Sync.ClosedStatuses = []string{"Done", "Won't Do"}
+			cfg.Links = LinksConfig{Backend: "yaml", Path: "/repo/gh2jira/links.yaml"}
+
+			var buf bytes.Buffer
+			Expect(Write(&buf, cfg)).To(Succeed())
+			Expect(buf.String()).To(HavePrefix("# gh2jira configuration file"))
+
+			parsed, err := Parse(buf.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(cfg))
+		})
+		It("should write the extra Jira fields", func() {
+			cfg := Defaults()
+			cfg.Jira.Fields = map[string]string{
+				"Story Points":   "3",
+				"Target Version": "{{ .GetMilestone.GetTitle }}",
+			}
+
+			var buf bytes.Buffer
+			Expect(Write(&buf, cfg)).To(Succeed())
+
+			parsed, err := Parse(buf.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(cfg))
+		})
+		It("should write the templates", func() {
+			cfg := Defaults()
+			cfg.Templates = []tmpl.Template{
+				{Name: "olm", Project: "operator-framework/*", Summary: "[OLM] {{ .GetTitle }}"},
+				{Name: "labels", Description: "{{ .GetBody | jira }}\n\nLabels: {{ labels .Labels | join \", \" }}"},
+			}
+
+			var buf bytes.Buffer
+			Expect(Write(&buf, cfg)).To(Succeed())
+
+			parsed, err := Parse(buf.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(cfg))
+		})
+		It("should write the user mapping", func() {
+			cfg := Defaults()
+			cfg.Users = UsersConfig{
+				Map:         map[string]string{"jmrodri": "jesusr", "jdoe": "jdoe1"},
+				SetReporter: true,
+			}
+
+			var buf bytes.Buffer
+			Expect(Write(&buf, cfg)).To(Succeed())
+
+			parsed, err := Parse(buf.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(cfg))
+		})
+		It("should write the label rules", func() {
+			cfg := Defaults()
+			cfg.Rules = []rules.Rule{
+				{Label: "kind/bug", IssueType: "Bug", Priority: "Major"},
+				{Label: "area/*", Labels: []string{"upstream"}, Components: []string{"SDK"}},
+			}
+
+			var buf bytes.Buffer
+			Expect(Write(&buf, cfg)).To(Succeed())
+
+			parsed, err := Parse(buf.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(cfg))
+		})
+	})
+
+	Describe("FromContext", func() {
+		It("should return the defaults if the context has no config", func() {
+			Expect(FromContext(context.Background())).To(Equal(Defaults()))
+		})
+		It("should return the config stored by NewContext", func() {
+			cfg := Defaults()
+			cfg.Jira.Project = "FOO"
+			ctx := NewContext(context.Background(), cfg)
+			Expect(FromContext(ctx)).To(BeIdenticalTo(cfg))
+		})
+	})
+})
@@ -0,0 +1,188 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var annotated = template.Must(template.New("config").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"list":  quoteList,
+}).Parse(`# gh2jira configuration file
+#
+# Every value below is the default for the matching command line flag; flags
+# given on the command line always win.
+
+github:
+  # Github project to list and clone issues from, e.g. ORG/REPO
+  project: {{ quote .Github.Project }}
+  # environment variable holding your Github personal access token
+  tokenEnv: {{ quote .Github.TokenEnv }}
+  # written back to Github issues cloned with --annotate-github
+  annotate:
+    # Go template of the comment, with the Jira .Key and .URL and the Github
+    # .Project and .Number. Empty for no comment.
+    comment: {{ quote .Github.Annotate.Comment }}
+    # label added to the Github issues, "list --exclude-label" hides them.
+    # Empty for no label.
+    label: {{ quote .Github.Annotate.Label }}
+
+jira:
+  # base URL of your Jira instance
+  url: {{ quote .Jira.URL }}
+  # Jira project key issues are cloned to
+  project: {{ quote .Jira.Project }}
+  # issue type of the cloned issues, e.g. Story, Bug, Task
+  issueType: {{ quote .Jira.IssueType }}
+  # key of the epic cloned issues are attached to, empty for none
+  epic: {{ quote .Jira.Epic }}
+  # environment variable holding your Jira personal access token
+  tokenEnv: {{ quote .Jira.TokenEnv }}
+  # add a remote link to the Github issue to the Links panel of cloned issues,
+  # clone and sync keep its title and state up to date
+  remoteLink: {{ .Jira.RemoteLink }}
+  # largest Github screenshot or file, in MiB, that clone --with-attachments
+  # uploads to Jira; larger ones stay linked to Github
+  maxAttachmentSize: {{ .Jira.MaxAttachmentSize }}
+  # extra fields set on cloned issues, by field name or ID. Values are Go
+  # templates executed on the Github issue, plain text is a static value.
+  # Run "gh2jira jira fields" to see the fields and their allowed values.
+{{- if .Jira.Fields }}
+  fields:
+{{- range $name, $value := .Jira.Fields }}
+    {{ quote $name }}: {{ quote $value }}
+{{- end }}
+{{- else }}
+  fields:
+  #  "Story Points": "3"
+  #  "Target Version": "{{ "{{ .GetMilestone.GetTitle }}" }}"
+{{- end }}
+
+sync:
+  # Jira statuses that count as closed when comparing with Github
+  closedStatuses: {{ list .Sync.ClosedStatuses }}
+  # Jira status to move an issue to when its Github issue is closed
+  closeStatus: {{ quote .Sync.CloseStatus }}
+  # Jira status to move an issue to when its Github issue is reopened
+  reopenStatus: {{ quote .Sync.ReopenStatus }}
+
+users:
+  # Github login to Jira username, the Github assignee becomes the Jira
+  # assignee. Clone prints the users it can not map.
+{{- if .Users.Map }}
+  map:
+{{- range $login, $name := .Users.Map }}
+    {{ quote $login }}: {{ quote $name }}
+{{- end }}
+{{- else }}
+  map:
+  #  "jmrodri": "jesusr"
+{{- end }}
+  # search Jira for the public email of Github users that are not in the map
+  lookupByEmail: {{ .Users.LookupByEmail }}
+  # make the Github author the Jira reporter, needs the Modify Reporter
+  # permission
+  setReporter: {{ .Users.SetReporter }}
+
+links:
+  # where clone records which Github issue became which Jira issue: json for
+  # a local file, or yaml for a file to commit to a git repository shared by
+  # the team
+  backend: {{ quote .Links.Backend }}
+  # path of the link file, empty for links.json or links.yaml in
+  # ~/.config/gh2jira
+  path: {{ quote .Links.Path }}
+
+# Rules set fields of the cloned Jira issue from the Github labels. The label
+# is an exact label or a glob like area/*. Rules are checked in order: the
+# first rule setting priority or issueType wins, labels and components from
+# every matching rule are combined.
+{{- if .Rules }}
+rules:
+{{- range .Rules }}
+  - label: {{ quote .Label }}
+{{- if .IssueType }}
+    issueType: {{ quote .IssueType }}
+{{- end }}
+{{- if .Priority }}
+    priority: {{ quote .Priority }}
+{{- end }}
+{{- if .Labels }}
+    labels: {{ list .Labels }}
+{{- end }}
+{{- if .Components }}
+    components: {{ list .Components }}
+{{- end }}
+{{- end }}
+{{- else }}
+rules:
+#  - label: "kind/bug"
+#    issueType: "Bug"
+#  - label: "kind/feature"
+#    issueType: "Story"
+#  - label: "priority/important-soon"
+#    priority: "Urgent"
+#  - label: "priority/important-longterm"
+#    priority: "Medium"
+#  - label: "area/*"
+#    labels: ["upstream"]
+#    components: ["SDK"]
+{{- end }}
+
+# Templates render the summary and description of cloned issues. They are Go
+# templates executed on the Github issue, e.g. {{ "{{ .GetTitle }}" }}, see the
+# README for the helper functions. The first template whose project, an
+# ORG/REPO or a glob, matches the Github project is used, or the one named
+# with clone --template. Preview one with "gh2jira render".
+{{- if .Templates }}
+templates:
+{{- range .Templates }}
+  - name: {{ quote .Name }}
+{{- if .Project }}
+    project: {{ quote .Project }}
+{{- end }}
+{{- if .Summary }}
+    summary: {{ quote .Summary }}
+{{- end }}
+{{- if .Description }}
+    description: {{ quote .Description }}
+{{- end }}
+{{- end }}
+{{- else }}
+templates:
+#  - name: "olm"
+#    project: "operator-framework/operator-lifecycle-manager"
+#    summary: "[OLM] {{ "{{" }} .GetTitle }} #{{ "{{" }} .GetNumber }}"
+#    description: "{{ "{{" }} .GetBody | jira }}\n\nLabels: {{ "{{" }} labels .Labels | join \", \" }}"
+{{- end }}
+`))
+
+// quoteList renders values as a YAML flow sequence of quoted strings.
+func quoteList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Write writes cfg to w as YAML annotated with comments describing each key.
+func Write(w io.Writer, cfg *Config) error {
+	return annotated.Execute(w, cfg)
+}
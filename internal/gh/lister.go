@@ -16,10 +16,13 @@ package gh
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	"golang.org/x/oauth2"
@@ -27,15 +30,31 @@ import (
 
 type Option func(*ListerConfig) error
 
+// ErrNoMilestone is returned when the project has no milestone by the
+// requested title.
+var ErrNoMilestone = errors.New("no milestone")
+
 type ListerConfig struct {
-	client    *http.Client
-	Milestone string
-	Assignee  string
-	Project   string
-	Label     []string
+	client       *http.Client
+	tokenEnv     string
+	Milestone    string
+	Assignee     string
+	Project      string
+	Label        []string
+	ExcludeLabel []string
+	State        string
+	Creator      string
+	Mentioned    string
+	Since        time.Time
+	Sort         string
+	Direction    string
+	Search       string
 }
 
 func (c *ListerConfig) setDefaults() error {
+	if c.State == "" {
+		c.State = "open"
+	}
 	if c.client == nil {
 		ctx := context.Background()
 		token, err := c.getToken()
@@ -62,10 +81,77 @@ func (l *ListerConfig) GetGithubRepo() string {
 	return s[1]
 }
 
+// resolveMilestone turns the configured milestone into the milestone number
+// the Github API expects. Numbers, "none" and "*" are passed through; anything
+// else is looked up by title, first exactly and then as a partial match.
+func (c *ListerConfig) resolveMilestone(client *github.Client) (string, error) {
+	m := strings.TrimSpace(c.Milestone)
+	if m == "" || m == "none" || m == "*" {
+		return m, nil
+	}
+	if _, err := strconv.Atoi(m); err == nil {
+		return m, nil
+	}
+
+	milestones, err := c.listMilestones(client)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []*github.Milestone
+	for _, ms := range milestones {
+		if strings.EqualFold(ms.GetTitle(), m) {
+			return strconv.Itoa(ms.GetNumber()), nil
+		}
+		if strings.Contains(strings.ToLower(ms.GetTitle()), strings.ToLower(m)) {
+			candidates = append(candidates, ms)
+		}
+	}
+
+	switch len(candidates) {
+	case 1:
+		return strconv.Itoa(candidates[0].GetNumber()), nil
+	case 0:
+		return "", fmt.Errorf("%w matching %q in %s", ErrNoMilestone, m, c.Project)
+	}
+
+	titles := make([]string, 0, len(candidates))
+	for _, ms := range candidates {
+		titles = append(titles, fmt.Sprintf("%q (%s)", ms.GetTitle(), ms.GetState()))
+	}
+	return "", fmt.Errorf("milestone %q is ambiguous, it matches: %s", m, strings.Join(titles, ", "))
+}
+
+// listMilestones returns the open and closed milestones of the project.
+func (c *ListerConfig) listMilestones(client *github.Client) ([]*github.Milestone, error) {
+	opt := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var milestones []*github.Milestone
+	for {
+		ms, resp, err := client.Issues.ListMilestones(context.Background(),
+			c.GetGithubOrg(), c.GetGithubRepo(), opt)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, ms...)
+		if resp.NextPage == 0 {
+			return milestones, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
 func (c *ListerConfig) getToken() (string, error) {
-	token, ok := os.LookupEnv("GITHUB_TOKEN")
+	env := c.tokenEnv
+	if env == "" {
+		env = "GITHUB_TOKEN"
+	}
+	token, ok := os.LookupEnv(env)
 	if !ok {
-		return "", fmt.Errorf("please supply your GITHUB_TOKEN")
+		return "", fmt.Errorf("please supply your %s", env)
 	}
 	return token, nil
 }
@@ -77,6 +163,13 @@ func WithClient(cl *http.Client) Option {
 	}
 }
 
+func WithTokenEnv(env string) Option {
+	return func(c *ListerConfig) error {
+		c.tokenEnv = env
+		return nil
+	}
+}
+
 func WithMilestone(m string) Option {
 	return func(c *ListerConfig) error {
 		c.Milestone = m
@@ -105,6 +198,124 @@ func WithLabel(l []string) Option {
 	}
 }
 
+func WithExcludeLabel(l []string) Option {
+	return func(c *ListerConfig) error {
+		c.ExcludeLabel = l
+		return nil
+	}
+}
+
+// WithState limits the issues to the given state: open, closed or all.
+func WithState(s string) Option {
+	return func(c *ListerConfig) error {
+		switch s {
+		case "", "open", "closed", "all":
+			c.State = s
+			return nil
+		}
+		return fmt.Errorf("invalid state %q, must be one of: open, closed, all", s)
+	}
+}
+
+func WithCreator(u string) Option {
+	return func(c *ListerConfig) error {
+		c.Creator = u
+		return nil
+	}
+}
+
+func WithMentioned(u string) Option {
+	return func(c *ListerConfig) error {
+		c.Mentioned = u
+		return nil
+	}
+}
+
+// WithSince only returns issues updated at or after the given time, either
+// an RFC 3339 timestamp or a YYYY-MM-DD date.
+func WithSince(s string) Option {
+	return func(c *ListerConfig) error {
+		if s == "" {
+			c.Since = time.Time{}
+			return nil
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				c.Since = t
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid since %q, must be a date like 2022-06-30 or 2022-06-30T15:04:05Z", s)
+	}
+}
+
+// WithSort orders the issues by created, updated or comments.
+func WithSort(s string) Option {
+	return func(c *ListerConfig) error {
+		switch s {
+		case "", "created", "updated", "comments":
+			c.Sort = s
+			return nil
+		}
+		return fmt.Errorf("invalid sort %q, must be one of: created, updated, comments", s)
+	}
+}
+
+// WithDirection sets the sort direction, asc or desc.
+func WithDirection(d string) Option {
+	return func(c *ListerConfig) error {
+		switch d {
+		case "", "asc", "desc":
+			c.Direction = d
+			return nil
+		}
+		return fmt.Errorf("invalid direction %q, must be asc or desc", d)
+	}
+}
+
+// WithSearch uses the Github search API with the given query instead of
+// listing the repository issues. The query is limited to the project unless
+// it already has a repo:, org: or user: qualifier.
+func WithSearch(q string) Option {
+	return func(c *ListerConfig) error {
+		c.Search = q
+		return nil
+	}
+}
+
+// searchQuery returns the query sent to the Github search API.
+func (c *ListerConfig) searchQuery() string {
+	if c.qualifiedSearch() {
+		return c.Search
+	}
+	return fmt.Sprintf("repo:%s %s", c.Project, c.Search)
+}
+
+// qualifiedSearch returns true if the search query picks its own
+// repositories with a repo:, org: or user: qualifier.
+func (c *ListerConfig) qualifiedSearch() bool {
+	for _, f := range strings.Fields(c.Search) {
+		for _, q := range []string{"repo:", "org:", "user:"} {
+			if strings.HasPrefix(f, q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// excluded returns true if the issue has one of the excluded labels.
+func (c *ListerConfig) excluded(issue *github.Issue) bool {
+	for _, l := range issue.Labels {
+		for _, ex := range c.ExcludeLabel {
+			if strings.EqualFold(l.GetName(), ex) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func GetIssue(issueNum int, opts ...Option) (*github.Issue, error) {
 	config := ListerConfig{}
 	for _, opt := range opts {
@@ -128,6 +339,189 @@ func GetIssue(issueNum int, opts ...Option) (*github.Issue, error) {
 	return issue, nil
 }
 
+// ListComments returns every comment on the Github issue, oldest first.
+func ListComments(issueNum int, opts ...Option) ([]*github.IssueComment, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	opt := &github.IssueListCommentsOptions{
+		Sort:        github.String("created"),
+		Direction:   github.String("asc"),
+		ListOptions: github.ListOptions{PerPage: 50},
+	}
+
+	var allComments []*github.IssueComment
+
+	for {
+		comments, resp, err := client.Issues.ListComments(context.Background(),
+			config.GetGithubOrg(), config.GetGithubRepo(), issueNum, opt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return allComments, nil
+}
+
+// ResolveMilestone returns the number of the milestone given with
+// WithMilestone: its number, title, or a unique part of the title.
+func ResolveMilestone(opts ...Option) (int, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return 0, err
+	}
+	if m := strings.TrimSpace(config.Milestone); m == "" || m == "none" || m == "*" {
+		return 0, fmt.Errorf("invalid milestone %q, give its title or number", config.Milestone)
+	}
+
+	client := github.NewClient(config.client)
+
+	num, err := config.resolveMilestone(client)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(num)
+}
+
+// ListMilestones returns the open and closed milestones of the project.
+func ListMilestones(opts ...Option) ([]*github.Milestone, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	return config.listMilestones(client)
+}
+
+// CreateIssue opens a Github issue in the project.
+func CreateIssue(req *github.IssueRequest, opts ...Option) (*github.Issue, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	issue, _, err := client.Issues.Create(context.Background(), config.GetGithubOrg(),
+		config.GetGithubRepo(), req)
+
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// SetIssueState opens or closes the Github issue, state must be either "open"
+// or "closed".
+func SetIssueState(issueNum int, state string, opts ...Option) (*github.Issue, error) {
+	if state != "open" && state != "closed" {
+		return nil, fmt.Errorf("invalid issue state %q, must be open or closed", state)
+	}
+
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	issue, _, err := client.Issues.Edit(context.Background(), config.GetGithubOrg(),
+		config.GetGithubRepo(), issueNum, &github.IssueRequest{State: github.String(state)})
+
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// AddComment posts a comment on the Github issue.
+func AddComment(issueNum int, body string, opts ...Option) (*github.IssueComment, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	comment, _, err := client.Issues.CreateComment(context.Background(), config.GetGithubOrg(),
+		config.GetGithubRepo(), issueNum, &github.IssueComment{Body: github.String(body)})
+
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// AddLabels adds the labels to the Github issue, Github creates labels the
+// project does not have yet.
+func AddLabels(issueNum int, labels []string, opts ...Option) error {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return err
+	}
+
+	client := github.NewClient(config.client)
+
+	_, _, err := client.Issues.AddLabelsToIssue(context.Background(), config.GetGithubOrg(),
+		config.GetGithubRepo(), issueNum, labels)
+	return err
+}
+
 func ListIssues(opts ...Option) ([]*github.Issue, error) {
 	config := ListerConfig{}
 	for _, opt := range opts {
@@ -142,19 +536,56 @@ func ListIssues(opts ...Option) ([]*github.Issue, error) {
 
 	client := github.NewClient(config.client)
 
+	var (
+		issues []*github.Issue
+		err    error
+	)
+	if config.Search != "" {
+		issues, err = config.searchIssues(client)
+	} else {
+		issues, err = config.listIssues(client)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.ExcludeLabel) == 0 {
+		return issues, nil
+	}
+
+	var allIssues []*github.Issue
+	for _, issue := range issues {
+		if !config.excluded(issue) {
+			allIssues = append(allIssues, issue)
+		}
+	}
+	return allIssues, nil
+}
+
+func (c *ListerConfig) listIssues(client *github.Client) ([]*github.Issue, error) {
+	milestone, err := c.resolveMilestone(client)
+	if err != nil {
+		return nil, err
+	}
+
 	opt := &github.IssueListByRepoOptions{
 		ListOptions: github.ListOptions{PerPage: 50},
-		State:       "open",
-		Milestone:   config.Milestone,
-		Assignee:    config.Assignee,
-		Labels:      config.Label,
+		State:       c.State,
+		Milestone:   milestone,
+		Assignee:    c.Assignee,
+		Creator:     c.Creator,
+		Mentioned:   c.Mentioned,
+		Labels:      c.Label,
+		Since:       c.Since,
+		Sort:        c.Sort,
+		Direction:   c.Direction,
 	}
 
 	var allIssues []*github.Issue
 
 	for {
 		issues, resp, err := client.Issues.ListByRepo(context.Background(),
-			config.GetGithubOrg(), config.GetGithubRepo(), opt)
+			c.GetGithubOrg(), c.GetGithubRepo(), opt)
 
 		if err != nil {
 			return nil, err
@@ -169,3 +600,28 @@ func ListIssues(opts ...Option) ([]*github.Issue, error) {
 
 	return allIssues, nil
 }
+
+func (c *ListerConfig) searchIssues(client *github.Client) ([]*github.Issue, error) {
+	opt := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 50},
+		Sort:        c.Sort,
+		Order:       c.Direction,
+	}
+
+	var allIssues []*github.Issue
+
+	for {
+		result, resp, err := client.Search.Issues(context.Background(), c.searchQuery(), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		allIssues = append(allIssues, result.Issues...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return allIssues, nil
+}
@@ -15,9 +15,12 @@
 package gh
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	"github.com/migueleliasweb/go-github-mock/src/mock"
@@ -77,6 +80,55 @@ var _ = Describe("Lister", func() {
 				Expect(options.GetGithubRepo()).To(Equal("operator-framework"))
 			})
 		})
+		Describe("resolveMilestone", func() {
+			var (
+				options ListerConfig
+				client  *github.Client
+			)
+			BeforeEach(func() {
+				options = ListerConfig{Project: "fakeorg/fakeproject"}
+				client = github.NewClient(mock.NewMockedHTTPClient(
+					mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+						[]github.Milestone{
+							{Number: github.Int(47), Title: github.String("v1.25.0"), State: github.String("open")},
+							{Number: github.Int(48), Title: github.String("v1.25.1"), State: github.String("open")},
+							{Number: github.Int(12), Title: github.String("v1.2"), State: github.String("closed")},
+							{Number: github.Int(50), Title: github.String("Backlog"), State: github.String("open")},
+						},
+					),
+				))
+			})
+			It("should pass numbers, none and * through", func() {
+				for _, m := range []string{"", "47", "none", "*"} {
+					options.Milestone = m
+					Expect(options.resolveMilestone(client)).To(Equal(m))
+				}
+			})
+			It("should find the milestone by title", func() {
+				options.Milestone = "V1.25.0"
+				Expect(options.resolveMilestone(client)).To(Equal("47"))
+			})
+			It("should prefer an exact title over partial matches", func() {
+				options.Milestone = "v1.2"
+				Expect(options.resolveMilestone(client)).To(Equal("12"))
+			})
+			It("should find the milestone by a unique partial title", func() {
+				options.Milestone = "back"
+				Expect(options.resolveMilestone(client)).To(Equal("50"))
+			})
+			It("should list the candidates if the title is ambiguous", func() {
+				options.Milestone = "v1.25"
+				_, err := options.resolveMilestone(client)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(`milestone "v1.25" is ambiguous, it matches: ` +
+					`"v1.25.0" (open), "v1.25.1" (open)`))
+			})
+			It("should return an error if nothing matches", func() {
+				options.Milestone = "v2"
+				_, err := options.resolveMilestone(client)
+				Expect(err).To(HaveOccurred())
+			})
+		})
 		Describe("getToken", func() {
 			var (
 				options       ListerConfig
@@ -107,6 +159,26 @@ var _ = Describe("Lister", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(token).To(Equal(""))
 			})
+			It("should read the token from the configured variable", func() {
+				err := os.Setenv("GH2JIRA_TEST_TOKEN", "from-other-env")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Unsetenv("GH2JIRA_TEST_TOKEN")
+
+				options.tokenEnv = "GH2JIRA_TEST_TOKEN"
+				token, err := options.getToken()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("from-other-env"))
+			})
+		})
+		Describe("searchQuery", func() {
+			It("should limit the query to the project", func() {
+				options := ListerConfig{Project: "fakeorg/fakeproject", Search: "label:bug"}
+				Expect(options.searchQuery()).To(Equal("repo:fakeorg/fakeproject label:bug"))
+			})
+			It("should keep a query with its own scope", func() {
+				options := ListerConfig{Project: "fakeorg/fakeproject", Search: "org:fakeorg label:bug"}
+				Expect(options.searchQuery()).To(Equal("org:fakeorg label:bug"))
+			})
 		})
 	})
 
@@ -132,6 +204,14 @@ var _ = Describe("Lister", func() {
 				Expect(options.client).To(Equal(mc))
 			})
 		})
+		Describe("WithTokenEnv", func() {
+			It("should set the token environment variable", func() {
+				opt := WithTokenEnv("GH_PAT")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.tokenEnv).To(Equal("GH_PAT"))
+			})
+		})
 		Describe("WithMilestone", func() {
 			It("should set the milestone", func() {
 				opt := WithMilestone("47")
@@ -165,6 +245,48 @@ var _ = Describe("Lister", func() {
 				Expect(options.Label).To(Equal(labels))
 			})
 		})
+		Describe("WithState", func() {
+			It("should set the state", func() {
+				for _, st := range []string{"open", "closed", "all"} {
+					Expect(WithState(st)(&options)).To(Succeed())
+					Expect(options.State).To(Equal(st))
+				}
+			})
+			It("should reject an unknown state", func() {
+				Expect(WithState("merged")(&options)).NotTo(Succeed())
+			})
+		})
+		Describe("WithSince", func() {
+			It("should parse a date", func() {
+				Expect(WithSince("2022-06-30")(&options)).To(Succeed())
+				Expect(options.Since).To(Equal(time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)))
+			})
+			It("should parse a timestamp", func() {
+				Expect(WithSince("2022-06-30T15:04:05Z")(&options)).To(Succeed())
+				Expect(options.Since).To(Equal(time.Date(2022, 6, 30, 15, 4, 5, 0, time.UTC)))
+			})
+			It("should reject anything else", func() {
+				Expect(WithSince("last week")(&options)).NotTo(Succeed())
+			})
+		})
+		Describe("WithSort", func() {
+			It("should set the sort", func() {
+				Expect(WithSort("updated")(&options)).To(Succeed())
+				Expect(options.Sort).To(Equal("updated"))
+			})
+			It("should reject an unknown sort", func() {
+				Expect(WithSort("title")(&options)).NotTo(Succeed())
+			})
+		})
+		Describe("WithDirection", func() {
+			It("should set the direction", func() {
+				Expect(WithDirection("asc")(&options)).To(Succeed())
+				Expect(options.Direction).To(Equal("asc"))
+			})
+			It("should reject an unknown direction", func() {
+				Expect(WithDirection("up")(&options)).NotTo(Succeed())
+			})
+		})
 	})
 
 	Describe("ListIssues", func() {
@@ -210,6 +332,99 @@ var _ = Describe("Lister", func() {
 			Expect(len(iss)).To(Equal(2))
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("should look up the milestone by title", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+					[]github.Milestone{
+						{Number: github.Int(47), Title: github.String("v1.25.0")},
+					},
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.URL.Query().Get("milestone")).To(Equal("47"))
+						w.Write(mock.MustMarshal([]github.Issue{
+							{ID: github.Int64(123), Title: github.String("Issue 1")},
+						}))
+					}),
+				),
+			)
+			iss, err := ListIssues(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"),
+				WithMilestone("v1.25.0"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(iss).To(HaveLen(1))
+		})
+		It("should pass the filters to Github", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						q := r.URL.Query()
+						Expect(q.Get("state")).To(Equal("closed"))
+						Expect(q.Get("creator")).To(Equal("jdoe"))
+						Expect(q.Get("mentioned")).To(Equal("jmrodri"))
+						Expect(q.Get("since")).To(Equal("2022-06-30T00:00:00Z"))
+						Expect(q.Get("sort")).To(Equal("updated"))
+						Expect(q.Get("direction")).To(Equal("asc"))
+						w.Write(mock.MustMarshal([]github.Issue{}))
+					}),
+				),
+			)
+			_, err := ListIssues(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"),
+				WithState("closed"),
+				WithCreator("jdoe"),
+				WithMentioned("jmrodri"),
+				WithSince("2022-06-30"),
+				WithSort("updated"),
+				WithDirection("asc"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should leave out issues with an excluded label", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepo,
+					[]github.Issue{
+						{
+							Number: github.Int(1),
+							Labels: []*github.Label{{Name: github.String("kind/bug")}},
+						},
+						{
+							Number: github.Int(2),
+							Labels: []*github.Label{{Name: github.String("WontFix")}},
+						},
+						{Number: github.Int(3)},
+					},
+				),
+			)
+			iss, err := ListIssues(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"),
+				WithExcludeLabel([]string{"wontfix", "duplicate"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(iss).To(HaveLen(2))
+			Expect(iss[0].GetNumber()).To(Equal(1))
+			Expect(iss[1].GetNumber()).To(Equal(3))
+		})
+		It("should use the search API when given a query", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetSearchIssues,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.URL.Query().Get("q")).To(Equal("repo:fakeorg/fakeproject is:open no:assignee"))
+						w.Write(mock.MustMarshal(github.IssuesSearchResult{
+							Total:  github.Int(1),
+							Issues: []*github.Issue{{Number: github.Int(7)}},
+						}))
+					}),
+				),
+			)
+			iss, err := ListIssues(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"),
+				WithSearch("is:open no:assignee"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(iss).To(HaveLen(1))
+			Expect(iss[0].GetNumber()).To(Equal(7))
+		})
 		It("should return error if list fails", func() {
 			// if our request returns an error ListIssues should return
 			// that error
@@ -283,4 +498,218 @@ var _ = Describe("Lister", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+	Describe("ListComments", func() {
+		var (
+			originalToken string
+		)
+		BeforeEach(func() {
+			originalToken = os.Getenv("GITHUB_TOKEN")
+			err := os.Setenv("GITHUB_TOKEN", "blah-blah-blah")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			err := os.Setenv("GITHUB_TOKEN", originalToken)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should return error if Options return an error", func() {
+			_, err := ListComments(10, func(c *ListerConfig) error {
+				return fmt.Errorf("do you see me")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("do you see me"))
+		})
+		It("should return the comments on the issue", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					[]github.IssueComment{
+						{Body: github.String("first")},
+						{Body: github.String("second")},
+					},
+				),
+			)
+			comments, err := ListComments(456, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(comments).To(HaveLen(2))
+			Expect(comments[1].GetBody()).To(Equal("second"))
+		})
+		It("should return error if listing fails", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(
+							w,
+							http.StatusInternalServerError,
+							"github went belly up or something",
+						)
+					}),
+				),
+			)
+			comments, err := ListComments(456, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(comments).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("ResolveMilestone", func() {
+		var (
+			originalToken string
+		)
+		BeforeEach(func() {
+			originalToken = os.Getenv("GITHUB_TOKEN")
+			err := os.Setenv("GITHUB_TOKEN", "blah-blah-blah")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			err := os.Setenv("GITHUB_TOKEN", originalToken)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should return an error for no milestone", func() {
+			for _, m := range []string{"", "none", "*"} {
+				_, err := ResolveMilestone(WithMilestone(m))
+				Expect(err).To(HaveOccurred())
+			}
+		})
+		It("should return the number of the milestone", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+					[]github.Milestone{
+						{Number: github.Int(47), Title: github.String("v1.25.0")},
+					},
+				),
+			)
+			num, err := ResolveMilestone(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"), WithMilestone("v1.25.0"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(num).To(Equal(47))
+		})
+		It("should return ErrNoMilestone for an unknown title", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+					[]github.Milestone{},
+				),
+			)
+			_, err := ResolveMilestone(WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"), WithMilestone("v9"))
+			Expect(errors.Is(err, ErrNoMilestone)).To(BeTrue())
+		})
+	})
+	Describe("CreateIssue", func() {
+		var (
+			originalToken string
+		)
+		BeforeEach(func() {
+			originalToken = os.Getenv("GITHUB_TOKEN")
+			err := os.Setenv("GITHUB_TOKEN", "blah-blah-blah")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			err := os.Setenv("GITHUB_TOKEN", originalToken)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should create the issue", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var req github.IssueRequest
+						Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+						Expect(req.GetTitle()).To(Equal("Jira title"))
+						Expect(*req.Labels).To(Equal([]string{"kind/bug"}))
+						w.Write(mock.MustMarshal(github.Issue{
+							Number: github.Int(789),
+							Title:  req.Title,
+						}))
+					}),
+				),
+			)
+			iss, err := CreateIssue(&github.IssueRequest{
+				Title:  github.String("Jira title"),
+				Labels: &[]string{"kind/bug"},
+			}, WithClient(mockedHTTPClient), WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(iss.GetNumber()).To(Equal(789))
+		})
+	})
+	Describe("AddComment and AddLabels", func() {
+		var (
+			originalToken string
+		)
+		BeforeEach(func() {
+			originalToken = os.Getenv("GITHUB_TOKEN")
+			err := os.Setenv("GITHUB_TOKEN", "blah-blah-blah")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			err := os.Setenv("GITHUB_TOKEN", originalToken)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should post the comment", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment github.IssueComment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						Expect(comment.GetBody()).To(Equal("Tracked downstream as OSDK-1"))
+						w.Write(mock.MustMarshal(comment))
+					}),
+				),
+			)
+			comment, err := AddComment(123, "Tracked downstream as OSDK-1",
+				WithClient(mockedHTTPClient), WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(comment.GetBody()).To(Equal("Tracked downstream as OSDK-1"))
+		})
+		It("should add the labels", func() {
+			var added []string
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesLabelsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&added)).To(Succeed())
+						w.Write(mock.MustMarshal([]github.Label{{Name: github.String("tracked-in-jira")}}))
+					}),
+				),
+			)
+			err := AddLabels(123, []string{"tracked-in-jira"},
+				WithClient(mockedHTTPClient), WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(Equal([]string{"tracked-in-jira"}))
+		})
+	})
+	Describe("SetIssueState", func() {
+		var (
+			originalToken string
+		)
+		BeforeEach(func() {
+			originalToken = os.Getenv("GITHUB_TOKEN")
+			err := os.Setenv("GITHUB_TOKEN", "blah-blah-blah")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			err := os.Setenv("GITHUB_TOKEN", originalToken)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should return an error for an unknown state", func() {
+			iss, err := SetIssueState(10, "merged")
+			Expect(iss).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+		It("should close the issue", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.PatchReposIssuesByOwnerByRepoByIssueNumber,
+					github.Issue{
+						Number: github.Int(456),
+						State:  github.String("closed"),
+					},
+				),
+			)
+			iss, err := SetIssueState(456, "closed", WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(iss.GetState()).To(Equal("closed"))
+		})
+	})
 })
@@ -15,9 +15,19 @@
 package gh
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
 	"github.com/google/go-github/v47/github"
+	"gopkg.in/yaml.v3"
 )
 
 // So we will want to allow this to be able to take in a specific GH issue id or
@@ -33,7 +43,231 @@ import (
 // gh2jira list --project operator-framework/operator-sdk [--milestone=] [--assignee=]
 // gh2jira copy GH# [--dry-run]
 
+// Formats lists the output formats understood by NewPrinter. A Go template is
+// given as template=TEMPLATE.
+var Formats = []string{"oneline", "long", "table", "json", "yaml", "csv", "template=TEMPLATE"}
+
+// Printer writes Github issues to w in a particular format.
+type Printer interface {
+	Print(w io.Writer, issues []*github.Issue) error
+}
+
+// PrinterFunc adapts a function to the Printer interface.
+type PrinterFunc func(w io.Writer, issues []*github.Issue) error
+
+func (f PrinterFunc) Print(w io.Writer, issues []*github.Issue) error {
+	return f(w, issues)
+}
+
+// NewPrinter returns the Printer for the given format, one of Formats. Color
+// only applies to the oneline format.
+func NewPrinter(format string, color bool) (Printer, error) {
+	switch {
+	case format == "" || format == "oneline":
+		return PrinterFunc(func(w io.Writer, issues []*github.Issue) error {
+			width := projectWidth(issues)
+			for _, issue := range issues {
+				if width > 0 {
+					if color {
+						fmt.Fprintf(w, "\033[36m%-*s\033[0m ", width, IssueProject(issue))
+					} else {
+						fmt.Fprintf(w, "%-*s ", width, IssueProject(issue))
+					}
+				}
+				FprintGithubIssue(w, issue, true, color)
+			}
+			return nil
+		}), nil
+	case format == "long":
+		return PrinterFunc(func(w io.Writer, issues []*github.Issue) error {
+			multi := multiProject(issues)
+			for _, issue := range issues {
+				if multi {
+					fmt.Fprintf(w, "Project:\t%s\n", IssueProject(issue))
+				}
+				FprintGithubIssue(w, issue, false, false)
+			}
+			return nil
+		}), nil
+	case format == "table":
+		return PrinterFunc(printTable), nil
+	case format == "json":
+		return PrinterFunc(printJSON), nil
+	case format == "yaml":
+		return PrinterFunc(printYAML), nil
+	case format == "csv":
+		return PrinterFunc(printCSV), nil
+	case strings.HasPrefix(format, "template="):
+		tmpl, err := template.New("issue").Parse(strings.TrimPrefix(format, "template="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return PrinterFunc(func(w io.Writer, issues []*github.Issue) error {
+			for _, issue := range issues {
+				if err := tmpl.Execute(w, issue); err != nil {
+					return err
+				}
+				fmt.Fprintln(w)
+			}
+			return nil
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, must be one of %s", format,
+		strings.Join(Formats, ", "))
+}
+
+// IssueRecord is the flattened form of a Github issue used by the
+// structured output formats.
+type IssueRecord struct {
+	Project     string   `json:"project,omitempty" yaml:"project,omitempty"`
+	Number      int      `json:"number" yaml:"number"`
+	Title       string   `json:"title" yaml:"title"`
+	State       string   `json:"state" yaml:"state"`
+	URL         string   `json:"url" yaml:"url"`
+	Author      string   `json:"author" yaml:"author"`
+	Assignee    string   `json:"assignee,omitempty" yaml:"assignee,omitempty"`
+	Milestone   string   `json:"milestone,omitempty" yaml:"milestone,omitempty"`
+	Labels      []string `json:"labels" yaml:"labels"`
+	CreatedAt   string   `json:"createdAt" yaml:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt" yaml:"updatedAt"`
+	PullRequest bool     `json:"pullRequest,omitempty" yaml:"pullRequest,omitempty"`
+}
+
+// NewIssueRecord flattens issue into an IssueRecord.
+func NewIssueRecord(issue *github.Issue) IssueRecord {
+	labels := []string{}
+	for _, l := range issue.Labels {
+		labels = append(labels, l.GetName())
+	}
+	return IssueRecord{
+		Project:     IssueProject(issue),
+		Number:      issue.GetNumber(),
+		Title:       issue.GetTitle(),
+		State:       issue.GetState(),
+		URL:         issue.GetHTMLURL(),
+		Author:      issue.GetUser().GetLogin(),
+		Assignee:    issue.GetAssignee().GetLogin(),
+		Milestone:   issue.GetMilestone().GetTitle(),
+		Labels:      labels,
+		CreatedAt:   formatTime(issue.GetCreatedAt()),
+		UpdatedAt:   formatTime(issue.GetUpdatedAt()),
+		PullRequest: issue.IsPullRequest(),
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// multiProject returns true if the issues come from more than one project,
+// in which case the printers show the project of each issue.
+func multiProject(issues []*github.Issue) bool {
+	for _, issue := range issues {
+		if IssueProject(issue) != IssueProject(issues[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectWidth returns the width of the project column of the oneline format,
+// zero when all the issues come from the same project.
+func projectWidth(issues []*github.Issue) int {
+	if !multiProject(issues) {
+		return 0
+	}
+	width := 0
+	for _, issue := range issues {
+		if l := len(IssueProject(issue)); l > width {
+			width = l
+		}
+	}
+	return width
+}
+
+// records flattens the issues, only filling in the project when they come
+// from more than one.
+func records(issues []*github.Issue) []IssueRecord {
+	multi := multiProject(issues)
+	recs := make([]IssueRecord, 0, len(issues))
+	for _, issue := range issues {
+		r := NewIssueRecord(issue)
+		if !multi {
+			r.Project = ""
+		}
+		recs = append(recs, r)
+	}
+	return recs
+}
+
+func printJSON(w io.Writer, issues []*github.Issue) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records(issues))
+}
+
+func printYAML(w io.Writer, issues []*github.Issue) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(records(issues)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func printCSV(w io.Writer, issues []*github.Issue) error {
+	multi := multiProject(issues)
+	cw := csv.NewWriter(w)
+	header := []string{"number", "title", "state", "url", "author", "assignee",
+		"milestone", "labels", "createdAt", "updatedAt"}
+	if multi {
+		header = append([]string{"project"}, header...)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records(issues) {
+		row := []string{strconv.Itoa(r.Number), r.Title, r.State, r.URL, r.Author,
+			r.Assignee, r.Milestone, strings.Join(r.Labels, ","), r.CreatedAt, r.UpdatedAt}
+		if multi {
+			row = append([]string{r.Project}, row...)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func printTable(w io.Writer, issues []*github.Issue) error {
+	multi := multiProject(issues)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if multi {
+		fmt.Fprint(tw, "PROJECT\t")
+	}
+	fmt.Fprintln(tw, "NUMBER\tSTATE\tASSIGNEE\tMILESTONE\tLABELS\tTITLE")
+	for _, r := range records(issues) {
+		if multi {
+			fmt.Fprintf(tw, "%s\t", r.Project)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", r.Number, r.State, r.Assignee, r.Milestone,
+			strings.Join(r.Labels, ","), r.Title)
+	}
+	return tw.Flush()
+}
+
+// PrintGithubIssue prints the issue to stdout, see FprintGithubIssue.
 func PrintGithubIssue(issue *github.Issue, oneline bool, color bool) {
+	FprintGithubIssue(os.Stdout, issue, oneline, color)
+}
+
+// FprintGithubIssue prints the issue to w, either on one line or in the long
+// form. Color is only used for the one line form.
+func FprintGithubIssue(w io.Writer, issue *github.Issue, oneline bool, color bool) {
 
 	// fmt.Printf("%5d %s %+v\n", issue.GetNumber(), issue.GetTitle(), issue.GetMilestone())
 	// return
@@ -41,22 +275,26 @@ func PrintGithubIssue(issue *github.Issue, oneline bool, color bool) {
 	if oneline {
 		if color {
 			// print the idea in yellow, then reset the rest of the line
-			fmt.Printf("\033[33m%5d\033[0m \033[32m%s\033[0m %s\n", issue.GetNumber(), issue.GetState(), issue.GetTitle())
+			fmt.Fprintf(w, "\033[33m%5d\033[0m \033[32m%s\033[0m %s\n", issue.GetNumber(), issue.GetState(), issue.GetTitle())
 		} else {
-			fmt.Printf("%5d %s %s\n", issue.GetNumber(), issue.GetState(), issue.GetTitle())
+			fmt.Fprintf(w, "%5d %s %s\n", issue.GetNumber(), issue.GetState(), issue.GetTitle())
 		}
 	} else {
 		// fmt.Println(*issue.ID)
-		fmt.Printf("Issue:\t%d\n", issue.GetNumber())
+		if issue.IsPullRequest() {
+			fmt.Fprintf(w, "Pull request:\t%d\n", issue.GetNumber())
+		} else {
+			fmt.Fprintf(w, "Issue:\t%d\n", issue.GetNumber())
+		}
 		// fmt.Println(*issue.Title)
-		fmt.Printf("State:\t%s\n", issue.GetState())
+		fmt.Fprintf(w, "State:\t%s\n", issue.GetState())
 		if issue.GetAssignee() != nil {
-			fmt.Printf("Assignee:\t%s\n", *issue.GetAssignee().Login)
+			fmt.Fprintf(w, "Assignee:\t%s\n", *issue.GetAssignee().Login)
 		}
 
 		// NOTE: This should be the jira body
 		// fmt.Printf("Title:\t%s\n", issue.GetTitle())
-		fmt.Printf("\n   %s\n\n", issue.GetTitle())
+		fmt.Fprintf(w, "\n   %s\n\n", issue.GetTitle())
 		// fmt.Printf("Body:\n\t%s\n", issue.GetBody())
 
 		// Look through the labels
@@ -15,9 +15,13 @@
 package gh
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 
@@ -102,4 +106,132 @@ var _ = Describe("Printer", func() {
 			Expect(expectedLong).To(Equal(string(stdout)))
 		})
 	})
+
+	Describe("NewPrinter", func() {
+		var (
+			issues []*github.Issue
+			buf    bytes.Buffer
+		)
+		BeforeEach(func() {
+			buf.Reset()
+			created := time.Date(2022, 9, 15, 13, 4, 0, 0, time.UTC)
+			issues = []*github.Issue{
+				{
+					Number:    github.Int(123),
+					Title:     github.String("Issue 1"),
+					State:     github.String("open"),
+					HTMLURL:   github.String("https://github.com/foo/bar/issues/123"),
+					User:      &github.User{Login: github.String("octocat")},
+					Assignee:  &github.User{Login: github.String("johndoe")},
+					Milestone: &github.Milestone{Title: github.String("v1.25.0")},
+					Labels: []*github.Label{
+						{Name: github.String("kind/bug")},
+						{Name: github.String("area/helm")},
+					},
+					CreatedAt: &created,
+					UpdatedAt: &created,
+				},
+				{
+					Number: github.Int(7),
+					Title:  github.String("Issue, with a comma"),
+					State:  github.String("closed"),
+				},
+			}
+		})
+		It("should return an error for an unknown format", func() {
+			_, err := NewPrinter("xml", false)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return an error for an invalid template", func() {
+			_, err := NewPrinter("template={{.Number", false)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should print one line per issue by default", func() {
+			p, err := NewPrinter("", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues)).To(Succeed())
+			Expect(buf.String()).To(Equal("  123 open Issue 1\n    7 closed Issue, with a comma\n"))
+		})
+		It("should print json", func() {
+			p, err := NewPrinter("json", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues)).To(Succeed())
+
+			var recs []IssueRecord
+			Expect(json.Unmarshal(buf.Bytes(), &recs)).To(Succeed())
+			Expect(recs).To(HaveLen(2))
+			Expect(recs[0]).To(Equal(IssueRecord{
+				Number:    123,
+				Title:     "Issue 1",
+				State:     "open",
+				URL:       "https://github.com/foo/bar/issues/123",
+				Author:    "octocat",
+				Assignee:  "johndoe",
+				Milestone: "v1.25.0",
+				Labels:    []string{"kind/bug", "area/helm"},
+				CreatedAt: "2022-09-15T13:04:00Z",
+				UpdatedAt: "2022-09-15T13:04:00Z",
+			}))
+			Expect(recs[1].Labels).To(BeEmpty())
+		})
+		It("should print yaml", func() {
+			p, err := NewPrinter("yaml", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues[1:])).To(Succeed())
+			Expect(buf.String()).To(Equal("- number: 7\n  title: Issue, with a comma\n  state: closed\n" +
+				"  url: \"\"\n  author: \"\"\n  labels: []\n  createdAt: \"\"\n  updatedAt: \"\"\n"))
+		})
+		It("should print csv with a header", func() {
+			p, err := NewPrinter("csv", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"number,title,state,url,author,assignee,milestone,labels,createdAt,updatedAt\n" +
+					"123,Issue 1,open,https://github.com/foo/bar/issues/123,octocat,johndoe,v1.25.0," +
+					"\"kind/bug,area/helm\",2022-09-15T13:04:00Z,2022-09-15T13:04:00Z\n" +
+					"7,\"Issue, with a comma\",closed,,,,,,,\n"))
+		})
+		It("should print an aligned table", func() {
+			p, err := NewPrinter("table", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"NUMBER  STATE   ASSIGNEE  MILESTONE  LABELS              TITLE\n" +
+					"123     open    johndoe   v1.25.0    kind/bug,area/helm  Issue 1\n" +
+					"7       closed                                           Issue, with a comma\n"))
+		})
+		It("should execute a template for each issue", func() {
+			p, err := NewPrinter("template={{.GetNumber}}: {{.GetTitle}}", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Print(&buf, issues)).To(Succeed())
+			Expect(buf.String()).To(Equal("123: Issue 1\n7: Issue, with a comma\n"))
+		})
+		Context("with issues from several projects", func() {
+			BeforeEach(func() {
+				issues[0].Repository = &github.Repository{FullName: github.String("foo/bar")}
+				issues[1].Repository = &github.Repository{FullName: github.String("foo/bazooka")}
+			})
+			It("should prefix each line with the project", func() {
+				p, err := NewPrinter("oneline", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.Print(&buf, issues)).To(Succeed())
+				Expect(buf.String()).To(Equal("foo/bar       123 open Issue 1\n" +
+					"foo/bazooka     7 closed Issue, with a comma\n"))
+			})
+			It("should add a project column to the table", func() {
+				p, err := NewPrinter("table", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.Print(&buf, issues)).To(Succeed())
+				Expect(strings.Split(buf.String(), "\n")[0]).To(HavePrefix("PROJECT      NUMBER"))
+			})
+			It("should add the project to the records", func() {
+				p, err := NewPrinter("json", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.Print(&buf, issues)).To(Succeed())
+				var recs []IssueRecord
+				Expect(json.Unmarshal(buf.Bytes(), &recs)).To(Succeed())
+				Expect(recs[1].Project).To(Equal("foo/bazooka"))
+			})
+		})
+	})
 })
@@ -0,0 +1,225 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// DefaultParallel is how many projects are fetched at the same time when none
+// is given.
+const DefaultParallel = 4
+
+// IssueProject returns the ORG/REPO the issue belongs to, or an empty string
+// if Github did not say.
+func IssueProject(issue *github.Issue) string {
+	if name := issue.GetRepository().GetFullName(); name != "" {
+		return name
+	}
+	// https://api.github.com/repos/operator-framework/operator-sdk
+	if u := issue.GetRepositoryURL(); u != "" {
+		parts := strings.Split(strings.TrimRight(u, "/"), "/")
+		if len(parts) >= 2 {
+			return strings.Join(parts[len(parts)-2:], "/")
+		}
+	}
+	return ""
+}
+
+// ExpandProjects turns the given ORG/REPO projects into a list of projects,
+// expanding globs such as operator-framework/* or operator-framework/helm-*
+// to the matching, not archived, repositories of the organization or user.
+func ExpandProjects(patterns []string, opts ...Option) ([]string, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	var projects []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			projects = append(projects, p)
+		}
+	}
+
+	var client *github.Client
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			add(pattern)
+			continue
+		}
+
+		org, repo, ok := strings.Cut(pattern, "/")
+		if !ok || strings.ContainsAny(org, "*?[") {
+			return nil, fmt.Errorf("invalid project %q, only the repository can be a glob e.g. ORG/*", pattern)
+		}
+		if _, err := path.Match(repo, ""); err != nil {
+			return nil, fmt.Errorf("invalid project %q: %w", pattern, err)
+		}
+
+		if client == nil {
+			if err := config.setDefaults(); err != nil {
+				return nil, err
+			}
+			client = github.NewClient(config.client)
+		}
+		repos, err := listRepos(client, org)
+		if err != nil {
+			return nil, err
+		}
+
+		var matched []string
+		for _, r := range repos {
+			if r.GetArchived() {
+				continue
+			}
+			if ok, _ := path.Match(repo, r.GetName()); ok {
+				matched = append(matched, fmt.Sprintf("%s/%s", org, r.GetName()))
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no repositories matching %q", pattern)
+		}
+		sort.Strings(matched)
+		for _, m := range matched {
+			add(m)
+		}
+	}
+	return projects, nil
+}
+
+// listRepos returns the repositories of the organization, or of the user if
+// there is no such organization.
+func listRepos(client *github.Client, owner string) ([]*github.Repository, error) {
+	var allRepos []*github.Repository
+
+	opt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := client.Repositories.ListByOrg(context.Background(), owner, opt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				break
+			}
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+		if resp.NextPage == 0 {
+			return allRepos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+
+	uopt := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := client.Repositories.List(context.Background(), owner, uopt)
+		if err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+		if resp.NextPage == 0 {
+			return allRepos, nil
+		}
+		uopt.Page = resp.NextPage
+	}
+}
+
+// ListAllIssues runs ListIssues for every project, fetching at most parallel
+// projects at the same time. The issues are returned in the order of the
+// projects, each with its Repository set or known from its RepositoryURL so
+// callers can tell them apart. When listing several projects, one without
+// the requested milestone is skipped rather than failing the run. A search
+// query with its own repo:, org: or user: qualifier is run only once.
+func ListAllIssues(projects []string, parallel int, opts ...Option) ([]*github.Issue, error) {
+	if parallel < 1 {
+		parallel = DefaultParallel
+	}
+
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Search != "" && config.qualifiedSearch() && len(projects) > 1 {
+		projects = projects[:1]
+	}
+
+	results := make([][]*github.Issue, len(projects))
+	errs := make([]error, len(projects))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, project := range projects {
+		wg.Add(1)
+		go func(i int, project string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			issues, err := ListIssues(append(opts[:len(opts):len(opts)], WithProject(project))...)
+			if err != nil {
+				if len(projects) > 1 && errors.Is(err, ErrNoMilestone) {
+					return
+				}
+				errs[i] = fmt.Errorf("%s: %w", project, err)
+				return
+			}
+			for _, issue := range issues {
+				// search results only carry their RepositoryURL, which
+				// IssueProject falls back to
+				if issue.Repository == nil && issue.RepositoryURL == nil {
+					issue.Repository = &github.Repository{FullName: github.String(project)}
+				}
+			}
+			results[i] = issues
+		}(i, project)
+	}
+	wg.Wait()
+
+	var allIssues []*github.Issue
+	seen := map[string]bool{}
+	for i := range projects {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for _, issue := range results[i] {
+			if u := issue.GetHTMLURL(); u != "" {
+				if seen[u] {
+					continue
+				}
+				seen[u] = true
+			}
+			allIssues = append(allIssues, issue)
+		}
+	}
+	return allIssues, nil
+}
@@ -0,0 +1,225 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Projects", func() {
+	Describe("IssueProject", func() {
+		It("should use the repository of the issue", func() {
+			issue := &github.Issue{Repository: &github.Repository{FullName: github.String("foo/bar")}}
+			Expect(IssueProject(issue)).To(Equal("foo/bar"))
+		})
+		It("should fall back to the repository url", func() {
+			issue := &github.Issue{RepositoryURL: github.String("https://api.github.com/repos/foo/bar")}
+			Expect(IssueProject(issue)).To(Equal("foo/bar"))
+		})
+		It("should return an empty string if it does not know", func() {
+			Expect(IssueProject(&github.Issue{})).To(BeEmpty())
+		})
+	})
+
+	Describe("ExpandProjects", func() {
+		repos := []github.Repository{
+			{Name: github.String("operator-sdk")},
+			{Name: github.String("helm-operator-plugins")},
+			{Name: github.String("helm-charts"), Archived: github.Bool(true)},
+			{Name: github.String("operator-lifecycle-manager")},
+		}
+		It("should pass projects without globs through", func() {
+			projects, err := ExpandProjects([]string{"foo/bar", "foo/baz", "foo/bar"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{"foo/bar", "foo/baz"}))
+		})
+		It("should expand a glob to the matching repositories", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetOrgsReposByOrg, repos),
+			)
+			projects, err := ExpandProjects([]string{"operator-framework/operator-sdk", "operator-framework/*operator*"},
+				WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{
+				"operator-framework/operator-sdk",
+				"operator-framework/helm-operator-plugins",
+				"operator-framework/operator-lifecycle-manager",
+			}))
+		})
+		It("should leave out archived repositories", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetOrgsReposByOrg, repos),
+			)
+			projects, err := ExpandProjects([]string{"operator-framework/helm-*"},
+				WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{"operator-framework/helm-operator-plugins"}))
+		})
+		It("should list the repositories of a user", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetOrgsReposByOrg,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "Not Found")
+					}),
+				),
+				mock.WithRequestMatch(mock.GetUsersReposByUsername, []github.Repository{
+					{Name: github.String("gh2jira")},
+				}),
+			)
+			projects, err := ExpandProjects([]string{"jmrodri/*"}, WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{"jmrodri/gh2jira"}))
+		})
+		It("should return an error if nothing matches", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetOrgsReposByOrg, repos),
+			)
+			_, err := ExpandProjects([]string{"operator-framework/ansible-*"},
+				WithClient(mockedHTTPClient))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should not allow a glob in the organization", func() {
+			_, err := ExpandProjects([]string{"operator-*/operator-sdk"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ListAllIssues", func() {
+		It("should return the issues of every project in order", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if strings.Contains(r.URL.Path, "/foo/bar/") {
+							w.Write(mock.MustMarshal([]github.Issue{{Number: github.Int(1)}, {Number: github.Int(2)}}))
+							return
+						}
+						w.Write(mock.MustMarshal([]github.Issue{{Number: github.Int(3)}}))
+					}),
+				),
+			)
+			issues, err := ListAllIssues([]string{"foo/bar", "foo/baz"}, 2, WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(3))
+			Expect(IssueProject(issues[0])).To(Equal("foo/bar"))
+			Expect(issues[1].GetNumber()).To(Equal(2))
+			Expect(IssueProject(issues[2])).To(Equal("foo/baz"))
+			Expect(issues[2].GetNumber()).To(Equal(3))
+		})
+		It("should skip projects without the milestone", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposMilestonesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if strings.Contains(r.URL.Path, "/foo/bar/") {
+							w.Write(mock.MustMarshal([]github.Milestone{
+								{Number: github.Int(4), Title: github.String("v1.0")},
+							}))
+							return
+						}
+						w.Write(mock.MustMarshal([]github.Milestone{}))
+					}),
+				),
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepo,
+					[]github.Issue{{Number: github.Int(1)}},
+				),
+			)
+			issues, err := ListAllIssues([]string{"foo/bar", "foo/baz"}, 1,
+				WithClient(mockedHTTPClient), WithMilestone("v1.0"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+			Expect(IssueProject(issues[0])).To(Equal("foo/bar"))
+		})
+		It("should keep the repository of search results", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetSearchIssues, github.IssuesSearchResult{
+					Total: github.Int(1),
+					Issues: []*github.Issue{{
+						Number:        github.Int(7),
+						HTMLURL:       github.String("https://github.com/other/x/issues/7"),
+						RepositoryURL: github.String("https://api.github.com/repos/other/x"),
+					}},
+				}),
+			)
+			issues, err := ListAllIssues([]string{"foo/bar"}, 1,
+				WithClient(mockedHTTPClient), WithSearch("repo:other/x is:open"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+			Expect(IssueProject(issues[0])).To(Equal("other/x"))
+		})
+		It("should run a qualified search once for several projects", func() {
+			searches := 0
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetSearchIssues,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						searches++
+						Expect(r.URL.Query().Get("q")).To(Equal("org:foo label:bug"))
+						w.Write(mock.MustMarshal(github.IssuesSearchResult{
+							Total: github.Int(1),
+							Issues: []*github.Issue{{
+								Number:        github.Int(7),
+								HTMLURL:       github.String("https://github.com/foo/baz/issues/7"),
+								RepositoryURL: github.String("https://api.github.com/repos/foo/baz"),
+							}},
+						}))
+					}),
+				),
+			)
+			issues, err := ListAllIssues([]string{"foo/bar", "foo/baz"}, 2,
+				WithClient(mockedHTTPClient), WithSearch("org:foo label:bug"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(searches).To(Equal(1))
+			Expect(issues).To(HaveLen(1))
+			Expect(IssueProject(issues[0])).To(Equal("foo/baz"))
+		})
+		It("should not return an issue twice", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetSearchIssues,
+					github.IssuesSearchResult{Total: github.Int(1), Issues: []*github.Issue{
+						{Number: github.Int(7), HTMLURL: github.String("https://github.com/foo/bar/issues/7")},
+					}},
+					github.IssuesSearchResult{Total: github.Int(1), Issues: []*github.Issue{
+						{Number: github.Int(7), HTMLURL: github.String("https://github.com/foo/bar/issues/7")},
+					}},
+				),
+			)
+			issues, err := ListAllIssues([]string{"foo/bar", "foo/baz"}, 1,
+				WithClient(mockedHTTPClient), WithSearch("is:open"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+		})
+		It("should name the project that failed", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusInternalServerError, "github went belly up")
+					}),
+				),
+			)
+			_, err := ListAllIssues([]string{"foo/bar"}, 0, WithClient(mockedHTTPClient))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("foo/bar: "))
+		})
+	})
+})
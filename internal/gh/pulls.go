@@ -0,0 +1,82 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// GetPullRequest returns the Github pull request with the given number.
+func GetPullRequest(prNum int, opts ...Option) (*github.PullRequest, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	pr, _, err := client.PullRequests.Get(context.Background(), config.GetGithubOrg(),
+		config.GetGithubRepo(), prNum)
+
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// ListReviews returns every review of the Github pull request, oldest first.
+func ListReviews(prNum int, opts ...Option) ([]*github.PullRequestReview, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(config.client)
+
+	opt := &github.ListOptions{PerPage: 50}
+
+	var allReviews []*github.PullRequestReview
+
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(context.Background(),
+			config.GetGithubOrg(), config.GetGithubRepo(), prNum, opt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allReviews = append(allReviews, reviews...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return allReviews, nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pull requests", func() {
+	Describe("GetPullRequest", func() {
+		It("should return error if Options return an error", func() {
+			_, err := GetPullRequest(10, func(c *ListerConfig) error {
+				return fmt.Errorf("do you see me")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("do you see me"))
+		})
+		It("should find the pull request", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposPullsByOwnerByRepoByPullNumber,
+					github.PullRequest{
+						Number: github.Int(124),
+						Head:   &github.PullRequestBranch{Label: github.String("jdoe:fix")},
+					},
+				),
+			)
+			pr, err := GetPullRequest(124, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pr.GetNumber()).To(Equal(124))
+			Expect(pr.GetHead().GetLabel()).To(Equal("jdoe:fix"))
+		})
+		It("should return error if the pull request can not be found", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposPullsByOwnerByRepoByPullNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "not found")
+					}),
+				),
+			)
+			pr, err := GetPullRequest(124, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(pr).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("ListReviews", func() {
+		It("should return the reviews of the pull request", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposPullsReviewsByOwnerByRepoByPullNumber,
+					[]github.PullRequestReview{
+						{State: github.String("COMMENTED")},
+						{State: github.String("APPROVED")},
+					},
+				),
+			)
+			reviews, err := ListReviews(124, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reviews).To(HaveLen(2))
+			Expect(reviews[1].GetState()).To(Equal("APPROVED"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// IssueRef points at an issue, or pull request, of a Github project.
+type IssueRef struct {
+	// Project is ORG/REPO, empty when only the number was given.
+	Project string
+	Number  int
+}
+
+func (r IssueRef) String() string {
+	if r.Project == "" {
+		return fmt.Sprintf("#%d", r.Number)
+	}
+	return fmt.Sprintf("%s#%d", r.Project, r.Number)
+}
+
+// ParseIssueRef parses an issue number, an ORG/REPO#NUMBER reference or a
+// https://github.com/ORG/REPO/issues/NUMBER (or /pull/NUMBER) URL.
+func ParseIssueRef(s string) (IssueRef, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") {
+		return parseIssueURL(s)
+	}
+
+	project, num, found := strings.Cut(s, "#")
+	if !found {
+		project, num = "", s
+	}
+	if found && project != "" {
+		org, repo, ok := strings.Cut(project, "/")
+		if !ok || org == "" || repo == "" || strings.Contains(repo, "/") {
+			return IssueRef{}, fmt.Errorf("invalid issue %q, the project must be ORG/REPO", s)
+		}
+	}
+
+	n, err := parseIssueNumber(num)
+	if err != nil {
+		return IssueRef{}, fmt.Errorf("invalid issue %q, %w", s, err)
+	}
+	return IssueRef{Project: project, Number: n}, nil
+}
+
+func parseIssueURL(s string) (IssueRef, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return IssueRef{}, fmt.Errorf("invalid issue url %q: %w", s, err)
+	}
+	if u.Host != "github.com" && u.Host != "www.github.com" {
+		return IssueRef{}, fmt.Errorf("invalid issue url %q, it is not a github.com url", s)
+	}
+
+	// /ORG/REPO/issues/NUMBER
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || (parts[2] != "issues" && parts[2] != "pull") {
+		return IssueRef{}, fmt.Errorf("invalid issue url %q, expected https://github.com/ORG/REPO/issues/NUMBER", s)
+	}
+	n, err := parseIssueNumber(parts[3])
+	if err != nil {
+		return IssueRef{}, fmt.Errorf("invalid issue url %q, %w", s, err)
+	}
+	return IssueRef{Project: parts[0] + "/" + parts[1], Number: n}, nil
+}
+
+func parseIssueNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not an issue number", s)
+	}
+	return n, nil
+}
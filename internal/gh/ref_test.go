@@ -0,0 +1,66 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IssueRef", func() {
+	Describe("ParseIssueRef", func() {
+		It("should parse a bare issue number", func() {
+			for _, s := range []string{"123", "#123", " 123 "} {
+				ref, err := ParseIssueRef(s)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ref).To(Equal(IssueRef{Number: 123}))
+			}
+		})
+		It("should parse an ORG/REPO#NUMBER reference", func() {
+			ref, err := ParseIssueRef("operator-framework/operator-sdk#5934")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(IssueRef{Project: "operator-framework/operator-sdk", Number: 5934}))
+			Expect(ref.String()).To(Equal("operator-framework/operator-sdk#5934"))
+		})
+		It("should parse issue and pull request urls", func() {
+			ref, err := ParseIssueRef("https://github.com/operator-framework/operator-sdk/issues/5934")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(IssueRef{Project: "operator-framework/operator-sdk", Number: 5934}))
+
+			ref, err = ParseIssueRef("https://github.com/foo/bar/pull/12/files#diff-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(IssueRef{Project: "foo/bar", Number: 12}))
+		})
+		It("should reject anything else", func() {
+			for _, s := range []string{
+				"",
+				"abc",
+				"0",
+				"-4",
+				"12a",
+				"foo#12",
+				"foo/bar/baz#12",
+				"foo/bar#",
+				"https://gitlab.com/foo/bar/issues/12",
+				"https://github.com/foo/bar",
+				"https://github.com/foo/bar/milestone/12",
+				"https://github.com/foo/bar/issues/new",
+			} {
+				_, err := ParseIssueRef(s)
+				Expect(err).To(HaveOccurred(), s)
+			}
+		})
+	})
+})
@@ -0,0 +1,44 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// GetUserEmail returns the public email of the Github user, empty if the
+// user does not show one.
+func GetUserEmail(login string, opts ...Option) (string, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return "", err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return "", err
+	}
+
+	client := github.NewClient(config.client)
+
+	user, _, err := client.Users.Get(context.Background(), login)
+	if err != nil {
+		return "", err
+	}
+	return user.GetEmail(), nil
+}
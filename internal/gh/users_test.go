@@ -0,0 +1,66 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Users", func() {
+	Describe("GetUserEmail", func() {
+		It("should return error if Options return an error", func() {
+			_, err := GetUserEmail("jdoe", func(c *ListerConfig) error {
+				return fmt.Errorf("do you see me")
+			})
+			Expect(err).To(MatchError("do you see me"))
+		})
+		It("should return the public email", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetUsersByUsername,
+					github.User{Login: github.String("jdoe"), Email: github.String("jdoe@example.com")},
+				),
+			)
+			email, err := GetUserEmail("jdoe", WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(email).To(Equal("jdoe@example.com"))
+		})
+		It("should return an empty email for users without a public one", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetUsersByUsername, github.User{Login: github.String("jdoe")}),
+			)
+			email, err := GetUserEmail("jdoe", WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(email).To(BeEmpty())
+		})
+		It("should return error if the user can not be found", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetUsersByUsername,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "not found")
+					}),
+				),
+			)
+			_, err := GetUserEmail("jdoe", WithClient(mockedHTTPClient))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
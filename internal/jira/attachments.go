@@ -0,0 +1,304 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// DefaultMaxAttachmentSize is the largest file mirrored from Github, 10 MiB.
+const DefaultMaxAttachmentSize = 10 << 20
+
+// downloadTimeout bounds the download of one attachment.
+const downloadTimeout = 2 * time.Minute
+
+// attachmentURL matches the URL of a file uploaded to a Github issue.
+const attachmentURL = `https://(?:user-images\.githubusercontent\.com|` +
+	`github\.com/user-attachments/(?:assets|files)|` +
+	`github\.com/[\w.-]+/[\w.-]+/(?:assets|files))/` +
+	`[^\s<>()\[\]{}|!"']*[^\s<>()\[\]{}|!"'.,;:]`
+
+var (
+	attachmentRE = regexp.MustCompile(attachmentURL)
+	// the ways markup.ToJira leaves a link to an attachment in the text
+	attachmentImageRE   = regexp.MustCompile(`!(` + attachmentURL + `)(?:\|[^!\n]*)?!`)
+	attachmentImgTagRE  = regexp.MustCompile(`<img\b[^>]*?\bsrc="(` + attachmentURL + `)"[^>]*>`)
+	attachmentLinkRE    = regexp.MustCompile(`\[([^|\]\n]*)\|(` + attachmentURL + `)\]`)
+	attachmentBracketRE = regexp.MustCompile(`\[(` + attachmentURL + `)\]`)
+)
+
+// attachmentURLs returns the Github attachment URLs in the texts, once each.
+func attachmentURLs(texts ...string) []string {
+	var (
+		urls []string
+		seen = map[string]bool{}
+	)
+	for _, t := range texts {
+		for _, u := range attachmentRE.FindAllString(t, -1) {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// rewriteAttachments points the links to the URLs in names at the attachments.
+func rewriteAttachments(text string, names map[string]string) string {
+	if len(names) == 0 {
+		return text
+	}
+	text = attachmentImgTagRE.ReplaceAllStringFunc(text, func(m string) string {
+		if name, ok := names[attachmentImgTagRE.FindStringSubmatch(m)[1]]; ok {
+			return fmt.Sprintf("!%s!", name)
+		}
+		return m
+	})
+	text = attachmentImageRE.ReplaceAllStringFunc(text, func(m string) string {
+		if name, ok := names[attachmentImageRE.FindStringSubmatch(m)[1]]; ok {
+			return fmt.Sprintf("!%s!", name)
+		}
+		return m
+	})
+	text = attachmentLinkRE.ReplaceAllStringFunc(text, func(m string) string {
+		sm := attachmentLinkRE.FindStringSubmatch(m)
+		if name, ok := names[sm[2]]; ok {
+			return fmt.Sprintf("[%s|^%s]", sm[1], name)
+		}
+		return m
+	})
+	text = attachmentBracketRE.ReplaceAllStringFunc(text, func(m string) string {
+		if name, ok := names[attachmentBracketRE.FindStringSubmatch(m)[1]]; ok {
+			return fmt.Sprintf("[^%s]", name)
+		}
+		return m
+	})
+	return attachmentRE.ReplaceAllStringFunc(text, func(m string) string {
+		if name, ok := names[m]; ok {
+			return fmt.Sprintf("[^%s]", name)
+		}
+		return m
+	})
+}
+
+// newDownloadClient returns a client sending token to github.com only.
+func newDownloadClient(token string, base http.RoundTripper) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Timeout:   downloadTimeout,
+		Transport: &githubAuthTransport{token: token, base: base},
+	}
+}
+
+type githubAuthTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *githubAuthTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.token == "" || !strings.EqualFold(r.URL.Hostname(), "github.com") {
+		return t.base.RoundTrip(r)
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "token "+t.token)
+	return t.base.RoundTrip(r)
+}
+
+// downloadAttachment returns the file at rawURL and its media type.
+func downloadAttachment(cl *http.Client, rawURL string, maxSize int64) ([]byte, string, error) {
+	resp, err := cl.Get(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, "", fmt.Errorf("%d bytes is over the %d byte limit", resp.ContentLength, maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", fmt.Errorf("over the %d byte limit", maxSize)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// attachmentName returns a file name for rawURL that is not in taken.
+func attachmentName(rawURL string, mediaType string, taken map[string]bool) string {
+	name := urlFileName(rawURL)
+	if path.Ext(name) == "" && mediaType != "" {
+		if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+			if exts, err := mime.ExtensionsByType(mt); err == nil && len(exts) > 0 {
+				name += exts[0]
+			}
+		}
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%d-%s", i, name)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// urlFileName returns the unescaped last path element of rawURL.
+func urlFileName(rawURL string) string {
+	name := path.Base(strings.SplitN(rawURL, "?", 2)[0])
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return name
+}
+
+// mirrorAttachments uploads the files at urls to the Jira issue key.
+func mirrorAttachments(jiraClient *gojira.Client, cl *http.Client, key string, urls []string,
+	maxSize int64, taken map[string]bool) map[string]string {
+
+	names := map[string]string{}
+	for _, u := range urls {
+		data, mediaType, err := downloadAttachment(cl, u, maxSize)
+		if err != nil {
+			fmt.Printf("Warning: unable to mirror %s: %v\n", u, err)
+			continue
+		}
+		name := attachmentName(u, mediaType, taken)
+		attached, resp, err := jiraClient.Issue.PostAttachment(key, bytes.NewReader(data), name)
+		if err != nil {
+			fmt.Printf("Warning: unable to attach %s to %s: %v\n", u, key, responseError(resp, err))
+			continue
+		}
+		// Jira may store the file under another name
+		if attached != nil && len(*attached) > 0 && (*attached)[0].Filename != "" {
+			name = (*attached)[0].Filename
+		}
+		names[u] = name
+	}
+	if len(names) > 0 {
+		fmt.Printf("Mirrored %d of %d attachments\n", len(names), len(urls))
+	}
+	return names
+}
+
+// attachToDescription mirrors urls and points the description of key at them.
+func attachToDescription(jiraClient *gojira.Client, config *ClonerConfig, key string,
+	description string, urls []string) map[string]string {
+
+	if len(urls) == 0 {
+		return nil
+	}
+	names := mirrorAttachments(jiraClient, config.downloadClient, key, urls, config.maxAttachmentSize,
+		map[string]bool{})
+	if len(names) == 0 {
+		return names
+	}
+	update := gojira.Issue{
+		Key:    key,
+		Fields: &gojira.IssueFields{Description: rewriteAttachments(description, names)},
+	}
+	if _, resp, err := jiraClient.Issue.Update(&update); err != nil {
+		fmt.Printf("Warning: unable to point the description of %s at its attachments: %v\n",
+			key, responseError(resp, err))
+	}
+	return names
+}
+
+// attachedNames maps the urls to the attachments mirrored from them.
+func attachedNames(attachments []*gojira.Attachment, urls []string) map[string]string {
+	names := map[string]string{}
+	used := map[string]bool{}
+	for _, u := range urls {
+		base := urlFileName(u)
+		for i := 1; i <= len(attachments) && names[u] == ""; i++ {
+			want := base
+			if i > 1 {
+				want = fmt.Sprintf("%d-%s", i, base)
+			}
+			for _, a := range attachments {
+				if a == nil || used[a.Filename] {
+					continue
+				}
+				if a.Filename == want ||
+					(path.Ext(base) == "" && strings.TrimSuffix(a.Filename, path.Ext(a.Filename)) == want) {
+					names[u] = a.Filename
+					used[a.Filename] = true
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// reattach points the description of key at the attachments it already has.
+func reattach(jiraClient *gojira.Client, config *ClonerConfig, key string, description string,
+	urls []string) string {
+
+	ji, resp, err := jiraClient.Issue.Get(key, &gojira.GetQueryOptions{Fields: "attachment"})
+	if err != nil {
+		fmt.Printf("Warning: unable to read the attachments of %s: %v\n", key, responseError(resp, err))
+		return description
+	}
+	var attachments []*gojira.Attachment
+	if ji != nil && ji.Fields != nil {
+		attachments = ji.Fields.Attachments
+	}
+	names := attachedNames(attachments, urls)
+
+	if config.attachments {
+		var missing []string
+		for _, u := range urls {
+			if _, ok := names[u]; !ok {
+				missing = append(missing, u)
+			}
+		}
+		if config.dryRun {
+			for _, u := range missing {
+				fmt.Printf("Attachment: %s\n", u)
+			}
+		} else if len(missing) > 0 {
+			taken := map[string]bool{}
+			for _, a := range attachments {
+				if a != nil {
+					taken[a.Filename] = true
+				}
+			}
+			mirrored := mirrorAttachments(jiraClient, config.downloadClient, key, missing,
+				config.maxAttachmentSize, taken)
+			for u, name := range mirrored {
+				names[u] = name
+			}
+		}
+	}
+	return rewriteAttachments(description, names)
+}
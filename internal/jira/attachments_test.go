@@ -0,0 +1,398 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+var (
+	getUserAttachmentAsset = jmock.EndpointPattern{
+		Pattern: "/user-attachments/assets/{id}",
+		Method:  "GET",
+	}
+	getUserAttachmentFile = jmock.EndpointPattern{
+		Pattern: "/user-attachments/files/{id}/{name}",
+		Method:  "GET",
+	}
+)
+
+var _ = Describe("Attachments", func() {
+	const (
+		screenshot = "https://github.com/user-attachments/assets/1234-abcd"
+		logFile    = "https://github.com/user-attachments/files/55/build.log"
+	)
+
+	Describe("attachmentURLs", func() {
+		It("should find the Github uploads once each", func() {
+			urls := attachmentURLs(
+				"see !"+screenshot+"! and [build.log|"+logFile+"].",
+				"again "+logFile+", and https://github.com/foo/bar/issues/1",
+				"old !https://user-images.githubusercontent.com/1/abc.png!",
+			)
+			Expect(urls).To(Equal([]string{screenshot, logFile,
+				"https://user-images.githubusercontent.com/1/abc.png"}))
+		})
+	})
+	Describe("rewriteAttachments", func() {
+		names := map[string]string{screenshot: "1234-abcd.png", logFile: "build.log"}
+
+		It("should refer to the attachments instead of Github", func() {
+			Expect(rewriteAttachments("!"+screenshot+"|width=500!", names)).To(Equal("!1234-abcd.png!"))
+			Expect(rewriteAttachments(`<img width="500" alt="shot" src="`+screenshot+`" />`, names)).
+				To(Equal("!1234-abcd.png!"))
+			Expect(rewriteAttachments("[the log|"+logFile+"]", names)).To(Equal("[the log|^build.log]"))
+			Expect(rewriteAttachments("["+logFile+"]", names)).To(Equal("[^build.log]"))
+			Expect(rewriteAttachments("see "+logFile+".", names)).To(Equal("see [^build.log]."))
+		})
+		It("should leave the files that were not mirrored alone", func() {
+			text := "[other|https://github.com/user-attachments/files/56/other.log]"
+			Expect(rewriteAttachments(text, names)).To(Equal(text))
+		})
+	})
+	Describe("attachmentName", func() {
+		It("should name the attachments after their URL", func() {
+			taken := map[string]bool{}
+			Expect(attachmentName(logFile, "text/plain", taken)).To(Equal("build.log"))
+			Expect(attachmentName(screenshot, "image/png", taken)).To(Equal("1234-abcd.png"))
+			Expect(attachmentName("https://github.com/user-attachments/files/56/build.log", "", taken)).
+				To(Equal("2-build.log"))
+			Expect(attachmentName("https://github.com/user-attachments/files/57/my%20notes.txt", "", taken)).
+				To(Equal("my notes.txt"))
+		})
+	})
+	Describe("attachedNames", func() {
+		It("should find the attachments mirrored from the URLs", func() {
+			attachments := []*gojira.Attachment{
+				{Filename: "build.log"},
+				{Filename: "1234-abcd.png"},
+				{Filename: "2-build.log"},
+			}
+			second := "https://github.com/user-attachments/files/56/build.log"
+			missing := "https://github.com/user-attachments/files/57/notes.txt"
+			Expect(attachedNames(attachments, []string{screenshot, logFile, second, missing})).To(Equal(
+				map[string]string{
+					screenshot: "1234-abcd.png",
+					logFile:    "build.log",
+					second:     "2-build.log",
+				}))
+		})
+	})
+	Describe("downloadAttachment", func() {
+		It("should refuse files over the size limit", func() {
+			downloads := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					getUserAttachmentFile,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Write([]byte(strings.Repeat("x", 100)))
+					}),
+				),
+			)
+			_, _, err := downloadAttachment(downloads, logFile, 10)
+			Expect(err).To(MatchError(ContainSubstring("over the 10 byte limit")))
+
+			data, _, err := downloadAttachment(downloads, logFile, 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(HaveLen(100))
+		})
+		It("should send the Github token to github.com only", func() {
+			var storageAuth []string
+			mocked := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					getUserAttachmentAsset,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						// uploads to private repositories need the token
+						if r.Header.Get("Authorization") != "token secret" {
+							w.WriteHeader(http.StatusNotFound)
+							return
+						}
+						http.Redirect(w, r, "https://private-user-images.githubusercontent.com/1/2.png?jwt=x",
+							http.StatusFound)
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.EndpointPattern{Pattern: "/1/2.png", Method: "GET"},
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						storageAuth = append(storageAuth, r.Header.Get("Authorization"))
+						w.Header().Set("Content-Type", "image/png")
+						w.Write([]byte("png"))
+					}),
+				),
+			)
+
+			_, _, err := downloadAttachment(newDownloadClient("", mocked.Transport), screenshot, 100)
+			Expect(err).To(MatchError(ContainSubstring("404")))
+
+			data, mediaType, err := downloadAttachment(newDownloadClient("secret", mocked.Transport),
+				screenshot, 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("png"))
+			Expect(mediaType).To(Equal("image/png"))
+			Expect(storageAuth).To(Equal([]string{""}))
+		})
+		It("should return an error if the file is missing", func() {
+			_, _, err := downloadAttachment(jmock.NewMockedHTTPClient(), logFile, 10)
+			Expect(err).To(MatchError(ContainSubstring("404")))
+		})
+	})
+	Describe("Clone", func() {
+		It("should mirror the attachments of the issue and its comments", func() {
+			var (
+				uploaded    []string
+				description string
+				comments    []string
+			)
+			downloads := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					getUserAttachmentAsset,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("Content-Type", "image/png")
+						w.Write([]byte("png"))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					getUserAttachmentFile,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Write([]byte("build output"))
+					}),
+				),
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueAttachmentsByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						f, header, err := r.FormFile("file")
+						Expect(err).NotTo(HaveOccurred())
+						data, _ := io.ReadAll(f)
+						uploaded = append(uploaded, header.Filename+": "+string(data))
+						w.Write(jmock.MustMarshal([]gojira.Attachment{{Filename: header.Filename}}))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var update gojira.Issue
+						Expect(json.NewDecoder(r.Body).Decode(&update)).To(Succeed())
+						description = update.Fields.Description
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment gojira.Comment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						comments = append(comments, comment.Body)
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+			)
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body:   github.String("It breaks:\n\n![screenshot](" + screenshot + ")"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+				WithRemoteLink(false),
+				WithComments([]*github.IssueComment{
+					{Body: github.String("log: [build.log](" + logFile + ")"), User: &github.User{Login: github.String("a")}},
+				}),
+				WithAttachments(true),
+				WithDownloadClient(downloads),
+			)
+			w.Close()
+			out, _ := io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("Mirrored 2 of 2 attachments"))
+			Expect(uploaded).To(Equal([]string{"1234-abcd.png: png", "build.log: build output"}))
+			Expect(description).To(HavePrefix("It breaks:\n\n!1234-abcd.png!"))
+			Expect(comments).To(HaveLen(1))
+			Expect(comments[0]).To(HaveSuffix("log: [build.log|^build.log]"))
+		})
+		It("should keep the Github link of a file it can not mirror", func() {
+			var updated bool
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						updated = true
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body:   github.String("![screenshot](" + screenshot + ")"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			created, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+				WithRemoteLink(false),
+				WithAttachments(true),
+				WithDownloadClient(jmock.NewMockedHTTPClient()),
+			)
+			w.Close()
+			out, _ := io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Key).To(Equal("OSDK-7"))
+			Expect(string(out)).To(ContainSubstring("Warning: unable to mirror " + screenshot))
+			Expect(updated).To(BeFalse())
+		})
+		It("should list the attachments in dry run mode", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+			)
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body:   github.String("![screenshot](" + screenshot + ")"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			plan := &Plan{}
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithDryRun(true),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+				WithAttachments(true),
+				WithPlan(plan),
+			)
+			w.Close()
+			out, _ := io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("Attachment: " + screenshot + "\n"))
+			Expect(plan.Issues[0].Attachments).To(Equal([]string{screenshot}))
+		})
+		It("should keep referring to the mirrored attachments when updating", func() {
+			var uploaded, description string
+			existing := gojira.Issue{
+				Key: "OSDK-42",
+				Fields: &gojira.IssueFields{
+					Summary: "[UPSTREAM] Issue 1 #123",
+					Description: "!1234-abcd.png!\n\nUpstream Github issue: " +
+						"https://github.com/foo/bar/issues/123\n",
+				},
+			}
+			downloads := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					getUserAttachmentFile,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Write([]byte("build output"))
+					}),
+				),
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key: "OSDK-42",
+					Fields: &gojira.IssueFields{
+						Attachments: []*gojira.Attachment{{Filename: "1234-abcd.png"}},
+					},
+				}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueAttachmentsByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, header, err := r.FormFile("file")
+						Expect(err).NotTo(HaveOccurred())
+						uploaded = header.Filename
+						w.Write(jmock.MustMarshal([]gojira.Attachment{{Filename: header.Filename}}))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var update gojira.Issue
+						Expect(json.NewDecoder(r.Body).Decode(&update)).To(Succeed())
+						description = update.Fields.Description
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body: github.String("It breaks:\n\n![screenshot](" + screenshot + ")\n\n" +
+					"log: [build.log](" + logFile + ")"),
+				URL: github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+				WithRemoteLink(false),
+				WithOnExisting(ExistingUpdate),
+				WithAttachments(true),
+				WithDownloadClient(downloads),
+			)
+			w.Close()
+			io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploaded).To(Equal("build.log"))
+			Expect(description).To(HavePrefix("It breaks:\n\n!1234-abcd.png!\n\nlog: [build.log|^build.log]"))
+			Expect(description).NotTo(ContainSubstring("user-attachments"))
+			Expect(jissue.Fields.Description).To(Equal(description))
+		})
+	})
+})
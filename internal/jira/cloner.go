@@ -15,22 +15,82 @@
 package jira
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"text/template"
 
 	gojira "github.com/andygrunwald/go-jira"
 	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/markup"
+	"github.com/jmrodri/gh2jira/internal/rules"
+	"github.com/jmrodri/gh2jira/internal/tmpl"
 )
 
 type Option func(*ClonerConfig) error
 
+// ExistingAction is what Clone does when the Github issue already has a Jira
+// clone in the target project.
+type ExistingAction string
+
+const (
+	// ExistingSkip leaves the existing Jira issue alone and returns it.
+	ExistingSkip ExistingAction = "skip"
+	// ExistingReport returns an AlreadyClonedError naming the existing issue.
+	ExistingReport ExistingAction = "report"
+	// ExistingUpdate rewrites the summary and description of the existing
+	// issue from the Github issue.
+	ExistingUpdate ExistingAction = "update"
+)
+
+// ExistingActions lists the valid ExistingAction values.
+var ExistingActions = []ExistingAction{ExistingSkip, ExistingReport, ExistingUpdate}
+
+// AlreadyClonedError is returned by Clone when the Github issue already has
+// a Jira clone and the ExistingAction is ExistingReport.
+type AlreadyClonedError struct {
+	Key string
+	URL string
+}
+
+func (e *AlreadyClonedError) Error() string {
+	return fmt.Sprintf("%s has already been cloned to %s", e.URL, e.Key)
+}
+
 type ClonerConfig struct {
-	client  *http.Client
-	dryRun  bool
-	project string
-	jiraURL string
+	client      *http.Client
+	tokenEnv    string
+	dryRun      bool
+	project     string
+	issueType   string
+	jiraURL     string
+	onExisting  ExistingAction
+	comments    []*github.IssueComment
+	rules       []rules.Rule
+	epic        *Epic
+	report      *Report
+	pr          *github.PullRequest
+	reviews     []*github.PullRequestReview
+	fields      map[string]*template.Template
+	fieldCache  *FieldCache
+	summary     *template.Template
+	description *template.Template
+	plan        *Plan
+	users       *Users
+	setReporter bool
+	links       links.Store
+	// noRemoteLink skips the Jira remote link to the Github issue.
+	noRemoteLink bool
+	annotator    Annotator
+	// attachments mirrors the files uploaded to the Github issue.
+	attachments       bool
+	maxAttachmentSize int64
+	downloadClient    *http.Client
+	githubTokenEnv    string
 }
 
 func (c *ClonerConfig) setDefaults() error {
@@ -48,13 +108,33 @@ func (c *ClonerConfig) setDefaults() error {
 	if c.jiraURL == "" {
 		c.jiraURL = "https://issues.redhat.com"
 	}
+	if c.issueType == "" {
+		c.issueType = "Story"
+	}
+	if c.onExisting == "" {
+		c.onExisting = ExistingSkip
+	}
+	if c.maxAttachmentSize <= 0 {
+		c.maxAttachmentSize = DefaultMaxAttachmentSize
+	}
+	if c.downloadClient == nil {
+		env := c.githubTokenEnv
+		if env == "" {
+			env = "GITHUB_TOKEN"
+		}
+		c.downloadClient = newDownloadClient(os.Getenv(env), nil)
+	}
 	return nil
 }
 
 func (c *ClonerConfig) getToken() (string, error) {
-	token, ok := os.LookupEnv("JIRA_TOKEN")
+	env := c.tokenEnv
+	if env == "" {
+		env = "JIRA_TOKEN"
+	}
+	token, ok := os.LookupEnv(env)
 	if !ok {
-		return "", fmt.Errorf("please supply your JIRA_TOKEN")
+		return "", fmt.Errorf("please supply your %s", env)
 	}
 	return token, nil
 }
@@ -66,6 +146,13 @@ func WithClient(cl *http.Client) Option {
 	}
 }
 
+func WithTokenEnv(env string) Option {
+	return func(c *ClonerConfig) error {
+		c.tokenEnv = env
+		return nil
+	}
+}
+
 func WithDryRun(dr bool) Option {
 	return func(c *ClonerConfig) error {
 		c.dryRun = dr
@@ -80,6 +167,13 @@ func WithProject(p string) Option {
 	}
 }
 
+func WithIssueType(t string) Option {
+	return func(c *ClonerConfig) error {
+		c.issueType = t
+		return nil
+	}
+}
+
 func WithJiraURL(j string) Option {
 	return func(c *ClonerConfig) error {
 		c.jiraURL = j
@@ -87,6 +181,208 @@ func WithJiraURL(j string) Option {
 	}
 }
 
+func WithOnExisting(a ExistingAction) Option {
+	return func(c *ClonerConfig) error {
+		for _, valid := range ExistingActions {
+			if a == valid {
+				c.onExisting = a
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid existing action %q, must be one of %v", a, ExistingActions)
+	}
+}
+
+// WithComments sets the Github comments to copy to the new Jira issue.
+func WithComments(comments []*github.IssueComment) Option {
+	return func(c *ClonerConfig) error {
+		c.comments = comments
+		return nil
+	}
+}
+
+// WithPullRequest adds the metadata of the pull request, and the state of its
+// reviews, to the description when cloning a pull request.
+func WithPullRequest(pr *github.PullRequest, reviews []*github.PullRequestReview) Option {
+	return func(c *ClonerConfig) error {
+		c.pr = pr
+		c.reviews = reviews
+		return nil
+	}
+}
+
+// WithFields sets extra fields by name or ID, each a template on the issue.
+func WithFields(fields map[string]string) Option {
+	return func(c *ClonerConfig) error {
+		tmpls, err := parseFieldTemplates(fields)
+		if err != nil {
+			return err
+		}
+		c.fields = tmpls
+		return nil
+	}
+}
+
+// WithTemplate renders the summary and description from TemplateData.
+func WithTemplate(summary string, description string) Option {
+	return func(c *ClonerConfig) error {
+		c.summary, c.description = nil, nil
+		if summary != "" {
+			t, err := tmpl.Parse("summary", summary)
+			if err != nil {
+				return err
+			}
+			c.summary = t
+		}
+		if description != "" {
+			t, err := tmpl.Parse("description", description)
+			if err != nil {
+				return err
+			}
+			c.description = t
+		}
+		return nil
+	}
+}
+
+// WithUsers sets the Jira assignee of the cloned issue from its Github
+// assignee, mapped to a Jira user by u.
+func WithUsers(u *Users) Option {
+	return func(c *ClonerConfig) error {
+		c.users = u
+		return nil
+	}
+}
+
+// WithReporter also sets the Jira reporter from the Github author when the
+// users are mapped with WithUsers.
+func WithReporter(set bool) Option {
+	return func(c *ClonerConfig) error {
+		c.setReporter = set
+		return nil
+	}
+}
+
+// WithPlan records the Jira requests of a dry run in p, so they can be
+// reviewed and made later with Apply.
+func WithPlan(p *Plan) Option {
+	return func(c *ClonerConfig) error {
+		c.plan = p
+		return nil
+	}
+}
+
+// WithRemoteLink sets whether the Jira issue gets a remote link to Github.
+func WithRemoteLink(add bool) Option {
+	return func(c *ClonerConfig) error {
+		c.noRemoteLink = !add
+		return nil
+	}
+}
+
+// Annotator writes back to the Github issue once it has been cloned to a
+// new Jira issue.
+type Annotator interface {
+	// Describe returns what Annotate would write, for dry runs.
+	Describe(project string, number int) string
+	// Annotate writes the Jira issue key on Github issue number of project.
+	Annotate(project string, number int, key string) error
+}
+
+// WithAnnotator writes back to the Github issue with a after it is cloned to
+// a new Jira issue. Issues that were already cloned are left alone.
+func WithAnnotator(a Annotator) Option {
+	return func(c *ClonerConfig) error {
+		c.annotator = a
+		return nil
+	}
+}
+
+// WithAttachments mirrors the files uploaded to Github as Jira attachments.
+func WithAttachments(mirror bool) Option {
+	return func(c *ClonerConfig) error {
+		c.attachments = mirror
+		return nil
+	}
+}
+
+// WithMaxAttachmentSize sets the largest file, in bytes, that is mirrored;
+// larger ones stay linked to Github. It defaults to DefaultMaxAttachmentSize.
+func WithMaxAttachmentSize(n int64) Option {
+	return func(c *ClonerConfig) error {
+		if n < 0 {
+			return fmt.Errorf("invalid attachment size limit %d", n)
+		}
+		c.maxAttachmentSize = n
+		return nil
+	}
+}
+
+// WithDownloadClient sets the client downloading the Github attachments.
+func WithDownloadClient(cl *http.Client) Option {
+	return func(c *ClonerConfig) error {
+		c.downloadClient = cl
+		return nil
+	}
+}
+
+// WithGithubTokenEnv sets the variable holding the Github download token.
+func WithGithubTokenEnv(env string) Option {
+	return func(c *ClonerConfig) error {
+		c.githubTokenEnv = env
+		return nil
+	}
+}
+
+// WithLinks records the link between the Github issue and its Jira clone in
+// the store.
+func WithLinks(store links.Store) Option {
+	return func(c *ClonerConfig) error {
+		c.links = store
+		return nil
+	}
+}
+
+// WithReport records the outcome of the clone in r, letting callers cloning
+// several issues summarize the run.
+func WithReport(r *Report) Option {
+	return func(c *ClonerConfig) error {
+		c.report = r
+		return nil
+	}
+}
+
+// WithEpic sets the epic the new Jira issue is attached to, as resolved once
+// by ResolveEpic. A nil epic attaches the issue to none.
+func WithEpic(e *Epic) Option {
+	return func(c *ClonerConfig) error {
+		c.epic = e
+		return nil
+	}
+}
+
+// WithFieldCache looks the create fields up in fc, shared by the clones of a
+// run, instead of asking Jira for every issue.
+func WithFieldCache(fc *FieldCache) Option {
+	return func(c *ClonerConfig) error {
+		c.fieldCache = fc
+		return nil
+	}
+}
+
+// WithRules sets the rules mapping Github labels to Jira fields.
+func WithRules(rs []rules.Rule) Option {
+	return func(c *ClonerConfig) error {
+		for _, r := range rs {
+			if err := r.Validate(); err != nil {
+				return err
+			}
+		}
+		c.rules = rs
+		return nil
+	}
+}
+
 func getWebURL(url string) string {
 	// https://api.github.com/repos/operator-framework/operator-sdk/issues/3447
 	// https://github.com/operator-framework/operator-sdk/issues/3447
@@ -96,6 +392,108 @@ func getWebURL(url string) string {
 	return strings.Replace(strings.Replace(url, "api.github.com", "github.com", 1), "repos/", "", 1)
 }
 
+// webURLProject returns the ORG/REPO of a Github issue web URL.
+func webURLProject(weburl string) string {
+	parts := strings.Split(strings.TrimPrefix(weburl, "https://github.com/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// BrowseURL returns the web URL of the Jira issue key on the Jira instance at
+// jiraURL.
+func BrowseURL(jiraURL string, key string) string {
+	return fmt.Sprintf("%s/browse/%s", strings.TrimRight(jiraURL, "/"), key)
+}
+
+// findExisting returns the Jira clone of the Github issue in project, or nil.
+func (c *ClonerConfig) findExisting(jiraClient *gojira.Client, project string, weburl string,
+	number int) (*gojira.Issue, error) {
+
+	if weburl == "" {
+		return nil, nil
+	}
+
+	if existing := c.findLinked(jiraClient, project, webURLProject(weburl), number); existing != nil {
+		return existing, nil
+	}
+
+	// issuesWithRemoteLinksByGlobalId is not available on every Jira
+	// instance, Jira rejects the query if it is missing
+	jql := fmt.Sprintf("project = %q AND (issue in issuesWithRemoteLinksByGlobalId(%q) OR description ~ %q)",
+		project, weburl, fmt.Sprintf("%q", weburl))
+	issues, resp, err := searchClones(jiraClient, jql)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusBadRequest {
+		jql = fmt.Sprintf("project = %q AND description ~ %q", project, fmt.Sprintf("%q", weburl))
+		issues, resp, err = searchClones(jiraClient, jql)
+	}
+	if err != nil {
+		return nil, responseError(resp, err)
+	}
+
+	// the text search is fuzzy, so the results are checked for the exact URL
+	marker := fmt.Sprintf("Upstream Github issue: %s\n", weburl)
+	for i := range issues {
+		if issues[i].Fields != nil && strings.Contains(issues[i].Fields.Description, marker) {
+			return &issues[i], nil
+		}
+	}
+	for i := range issues {
+		if hasRemoteLink(jiraClient, issues[i].Key, weburl) {
+			return &issues[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// searchClones runs the JQL search of findExisting.
+func searchClones(jiraClient *gojira.Client, jql string) ([]gojira.Issue, *gojira.Response, error) {
+	return jiraClient.Issue.Search(jql, &gojira.SearchOptions{
+		MaxResults: 50,
+		Fields:     []string{"summary", "description"},
+	})
+}
+
+// findLinked returns the Jira issue the link store links to the Github issue.
+func (c *ClonerConfig) findLinked(jiraClient *gojira.Client, project string, ghproject string,
+	number int) *gojira.Issue {
+
+	if c.links == nil {
+		return nil
+	}
+	found, err := c.links.Find(links.GithubRef(ghproject, number), "")
+	if err != nil {
+		fmt.Printf("Warning: unable to read the link store: %v\n", err)
+		return nil
+	}
+	for _, l := range found {
+		if !strings.HasPrefix(l.Jira, project+"-") {
+			continue
+		}
+		existing, _, err := jiraClient.Issue.Get(l.Jira, &gojira.GetQueryOptions{Fields: "summary,description"})
+		if err == nil && existing != nil {
+			return existing
+		}
+	}
+	return nil
+}
+
+// hasRemoteLink returns true if the Jira issue key has a remote link to the
+// Github issue at weburl.
+func hasRemoteLink(jiraClient *gojira.Client, key string, weburl string) bool {
+	remoteLinks, _, err := jiraClient.Issue.GetRemoteLinks(key)
+	if err != nil || remoteLinks == nil {
+		return false
+	}
+	for _, l := range *remoteLinks {
+		if l.GlobalID == weburl {
+			return true
+		}
+	}
+	return false
+}
+
 func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 	config := ClonerConfig{}
 	for _, opt := range opts {
@@ -104,57 +502,466 @@ func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 		}
 	}
 
-	if err := config.setDefaults(); err != nil {
-		return nil, err
+	var (
+		daIssue *gojira.Issue
+		outcome = Failed
+	)
+	err := config.setDefaults()
+	if err == nil {
+		daIssue, outcome, err = clone(&config, issue)
+	}
+	config.recordLink(webURLProject(getWebURL(issue.GetURL())), issue.GetNumber(), daIssue, outcome)
+	config.annotate(webURLProject(getWebURL(issue.GetURL())), issue.GetNumber(), daIssue, outcome)
+	if config.report != nil {
+		entry := ReportEntry{
+			Project: webURLProject(getWebURL(issue.GetURL())),
+			Number:  issue.GetNumber(),
+			URL:     getWebURL(issue.GetURL()),
+			Outcome: outcome,
+			Err:     err,
+		}
+		if daIssue != nil {
+			entry.Key = daIssue.Key
+		}
+		config.report.Add(entry)
 	}
+	return daIssue, err
+}
 
+// clone does the work of Clone and returns what it did with the issue.
+func clone(config *ClonerConfig, issue *github.Issue) (*gojira.Issue, Outcome, error) {
 	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
 	if err != nil {
-		return nil, err
+		return nil, Failed, err
+	}
+
+	weburl := getWebURL(issue.GetURL())
+
+	isPR := issue.GetPullRequestLinks() != nil
+	kind := "issue"
+	if isPR {
+		kind = "pull request"
+	}
+	summary, description, err := config.render(issue)
+	if err != nil {
+		return nil, Failed, err
 	}
 
 	ji := gojira.Issue{
 		Fields: &gojira.IssueFields{
-			// Assignee: &gojira.User{
-			//     Name: "myuser",
-			// },
-			// Reporter: &gojira.User{
-			//     Name: "youruser",
-			// },
-			Description: fmt.Sprintf("%s\n\nUpstream Github issue: %s\n", issue.GetBody(), getWebURL(issue.GetURL())),
+			Description: upstreamDescription(description, weburl),
 			Type: gojira.IssueType{
-				Name: "Story",
+				Name: config.issueType,
 			},
 			Project: gojira.Project{
 				Key: config.project,
 			},
-			Summary: fmt.Sprintf("[UPSTREAM] %s #%d", issue.GetTitle(), issue.GetNumber()),
+			Summary: summary,
 		},
 	}
 
+	var epicLink string
+	if config.epic != nil {
+		epicLink = config.epic.attach(&ji)
+	}
+
+	existing, err := config.findExisting(jiraClient, config.project, weburl, issue.GetNumber())
+	if err != nil {
+		return nil, Failed, err
+	}
+
+	if existing != nil {
+		return handleExisting(jiraClient, config, issue, existing, &ji)
+	}
+
+	fired := applyRules(&ji, issue, config.rules)
+	if isPR {
+		ji.Fields.Labels = appendLabel(ji.Fields.Labels, PullRequestLabel)
+	}
+
+	var settings []FieldSetting
+	if len(config.fields) > 0 {
+		if settings, err = applyFields(jiraClient, config, &ji, issue); err != nil {
+			return nil, Failed, err
+		}
+	}
+
+	var userNotes []userNote
+	if config.users != nil {
+		if userNotes, err = applyUsers(jiraClient, config, &ji, issue); err != nil {
+			return nil, Failed, err
+		}
+	}
+
 	var daIssue *gojira.Issue
+	outcome := Planned
 
 	if config.dryRun {
 		fmt.Println("\n############# DRY RUN MODE #############")
-		fmt.Printf("Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
+		fmt.Printf("Cloning %s #%d to jira project board: %s\n\n", kind, issue.GetNumber(), ji.Fields.Project.Key)
 		fmt.Printf("Summary: %s\n", ji.Fields.Summary)
 		fmt.Printf("Type: %s\n", ji.Fields.Type.Name)
+		if ji.Fields.Priority != nil {
+			fmt.Printf("Priority: %s\n", ji.Fields.Priority.Name)
+		}
+		if len(ji.Fields.Labels) > 0 {
+			fmt.Printf("Labels: %s\n", strings.Join(ji.Fields.Labels, ", "))
+		}
+		if len(ji.Fields.Components) > 0 {
+			names := make([]string, 0, len(ji.Fields.Components))
+			for _, c := range ji.Fields.Components {
+				names = append(names, c.Name)
+			}
+			fmt.Printf("Components: %s\n", strings.Join(names, ", "))
+		}
+		if epicLink != "" {
+			fmt.Printf("Epic: %s (%s)\n", config.epic.Key, epicLink)
+		}
+		for _, m := range fired {
+			fmt.Printf("Rule fired: %s\n", m)
+		}
+		for _, f := range settings {
+			fmt.Printf("Field: %s\n", f)
+		}
+		for _, n := range userNotes {
+			fmt.Println(n)
+		}
+		if link := config.remoteLink(issue); link != nil {
+			fmt.Printf("Remote link: %s %s (%s)\n", link.Object.Title, link.Object.URL,
+				link.Object.Status.Icon.Title)
+		}
+		if config.annotator != nil {
+			fmt.Printf("Github: %s\n", config.annotator.Describe(webURLProject(weburl), issue.GetNumber()))
+		}
+		for _, u := range config.attachmentURLs(&ji) {
+			fmt.Printf("Attachment: %s\n", u)
+		}
 		fmt.Println("Description:")
 		fmt.Printf("%s\n", ji.Fields.Description)
+		if len(config.comments) > 0 {
+			fmt.Printf("\nComments: %d\n", len(config.comments))
+			for _, comment := range config.comments {
+				fmt.Printf("%s\n", commentBody(comment))
+			}
+		}
+		if err := printPayload("POST /rest/api/2/issue", &ji); err != nil {
+			return nil, Failed, err
+		}
 		fmt.Println("\n############# DRY RUN MODE #############")
+
+		if config.plan != nil {
+			planned := PlannedIssue{
+				Project: webURLProject(weburl),
+				Number:  issue.GetNumber(),
+				URL:     weburl,
+				Payload: &ji,
+			}
+			for _, comment := range config.comments {
+				planned.Comments = append(planned.Comments, commentBody(comment))
+			}
+			planned.RemoteLink = config.remoteLink(issue)
+			planned.Attachments = config.attachmentURLs(&ji)
+			config.plan.Add(planned)
+		}
 	} else {
-		fmt.Printf("Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
-		var err error
-		daIssue, _, err = jiraClient.Issue.Create(&ji)
+		fmt.Printf("Cloning %s #%d to jira project board: %s\n\n", kind, issue.GetNumber(), ji.Fields.Project.Key)
+		for _, n := range userNotes {
+			if n.unset {
+				fmt.Printf("Warning: %s\n", n)
+			}
+		}
+		var (
+			resp *gojira.Response
+			err  error
+		)
+		daIssue, resp, err = jiraClient.Issue.Create(&ji)
 		if err != nil {
-			return daIssue, err
+			return daIssue, Failed, responseError(resp, err)
 		}
+		outcome = Created
 
 		if daIssue != nil {
-			fmt.Printf("Issue cloned; see %s\n",
-				fmt.Sprintf("https://issues.redhat.com/browse/%s", daIssue.Key))
+			fmt.Printf("Issue cloned; see %s\n", BrowseURL(config.jiraURL, daIssue.Key))
+
+			names := attachToDescription(jiraClient, config, daIssue.Key, ji.Fields.Description,
+				config.attachmentURLs(&ji))
+
+			copied := 0
+			for _, comment := range config.comments {
+				if _, resp, err := jiraClient.Issue.AddComment(daIssue.Key, &gojira.Comment{
+					Body: rewriteAttachments(commentBody(comment), names),
+				}); err != nil {
+					fmt.Printf("Warning: unable to copy comment %s: %v\n",
+						comment.GetHTMLURL(), responseError(resp, err))
+					continue
+				}
+				copied++
+			}
+			if len(config.comments) > 0 {
+				fmt.Printf("Copied %d of %d comments\n", copied, len(config.comments))
+			}
+			if link := config.remoteLink(issue); link != nil {
+				addRemoteLink(jiraClient, daIssue.Key, link)
+			}
 		}
 	}
 
-	return daIssue, nil
+	return daIssue, outcome, nil
+}
+
+// recordLink adds the Github issue and ji to the link store.
+func (c *ClonerConfig) recordLink(project string, number int, ji *gojira.Issue, outcome Outcome) {
+	if c.links == nil || c.dryRun || ji == nil || ji.Key == "" || outcome == Planned {
+		return
+	}
+	l := links.Link{Github: links.GithubRef(project, number), Jira: ji.Key}
+	if _, err := c.links.Add(l); err != nil {
+		fmt.Printf("Warning: unable to record %s in the link store: %v\n", l, err)
+	}
+}
+
+// attachmentURLs returns the attachments of ji and its comments to mirror.
+func (c *ClonerConfig) attachmentURLs(ji *gojira.Issue) []string {
+	if !c.attachments {
+		return nil
+	}
+	texts := []string{ji.Fields.Description}
+	for _, comment := range c.comments {
+		texts = append(texts, commentBody(comment))
+	}
+	return attachmentURLs(texts...)
+}
+
+// annotate writes back to the Github issue cloned to the new Jira issue ji.
+func (c *ClonerConfig) annotate(project string, number int, ji *gojira.Issue, outcome Outcome) {
+	if c.annotator == nil || c.dryRun || ji == nil || ji.Key == "" || outcome != Created {
+		return
+	}
+	if err := c.annotator.Annotate(project, number, ji.Key); err != nil {
+		fmt.Printf("Warning: unable to annotate %s#%d: %v\n", project, number, err)
+	}
+}
+
+// printPayload prints the JSON body of the request Jira would be sent.
+func printPayload(request string, ji *gojira.Issue) error {
+	payload, err := json.MarshalIndent(ji, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nPayload (%s):\n%s\n", request, payload)
+	return nil
+}
+
+// epicLinkSchema is the custom field type of the classic Epic Link field.
+const epicLinkSchema = "com.pyxis.greenhopper.jira:gh-epic-link"
+
+// epicLinkField returns the ID of the classic Epic Link custom field, or an
+// empty string if the Jira instance does not have one.
+func epicLinkField(jiraClient *gojira.Client) (string, error) {
+	fields, resp, err := jiraClient.Field.GetList()
+	if err != nil {
+		return "", responseError(resp, err)
+	}
+	for _, f := range fields {
+		if f.Schema.Custom == epicLinkSchema {
+			return f.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// Epic is an epic cloned issues are attached to.
+type Epic struct {
+	Key string
+	// LinkField is the ID of the classic Epic Link field, empty when the
+	// instance has none and the parent field is used instead.
+	LinkField string
+}
+
+// ResolveEpic checks the epic key and finds out how issues are attached to it.
+func ResolveEpic(key string, opts ...Option) (*Epic, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	e, resp, err := jiraClient.Issue.Get(key, &gojira.GetQueryOptions{Fields: "issuetype,summary"})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find epic %s: %w", key, responseError(resp, err))
+	}
+
+	fieldID, err := epicLinkField(jiraClient)
+	if err != nil {
+		return nil, err
+	}
+	if fieldID == "" {
+		return &Epic{Key: key}, nil
+	}
+
+	if e.Fields == nil || !strings.EqualFold(e.Fields.Type.Name, "Epic") {
+		typeName := "issue"
+		if e.Fields != nil && e.Fields.Type.Name != "" {
+			typeName = e.Fields.Type.Name
+		}
+		return nil, fmt.Errorf("%s is a %s, not an Epic", key, typeName)
+	}
+	return &Epic{Key: key, LinkField: fieldID}, nil
+}
+
+// attach attaches ji to the epic and returns how.
+func (e *Epic) attach(ji *gojira.Issue) string {
+	if e.LinkField == "" {
+		ji.Fields.Parent = &gojira.Parent{Key: e.Key}
+		return "via parent"
+	}
+	if ji.Fields.Unknowns == nil {
+		ji.Fields.Unknowns = map[string]interface{}{}
+	}
+	ji.Fields.Unknowns[e.LinkField] = e.Key
+	return fmt.Sprintf("via Epic Link %s", e.LinkField)
+}
+
+// applyFields sets the configured fields on ji, checking them and the
+// required fields against the create metadata of the project and issue type.
+func applyFields(jiraClient *gojira.Client, config *ClonerConfig, ji *gojira.Issue,
+	issue *github.Issue) ([]FieldSetting, error) {
+
+	fields, err := config.fieldCache.get(jiraClient, config.project, ji.Fields.Type.Name)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := fieldSettings(fields, config.fields, issue)
+	if err != nil {
+		return nil, err
+	}
+
+	if ji.Fields.Unknowns == nil {
+		ji.Fields.Unknowns = map[string]interface{}{}
+	}
+	for _, s := range settings {
+		ji.Fields.Unknowns[s.Field.ID] = s.Value
+	}
+
+	if missing := missingRequired(fields, ji); len(missing) > 0 {
+		return nil, fmt.Errorf("required fields are not set: %s", strings.Join(missing, ", "))
+	}
+	return settings, nil
+}
+
+// applyRules sets the fields of ji chosen by the label rules and returns the
+// rules that fired.
+func applyRules(ji *gojira.Issue, issue *github.Issue, rs []rules.Rule) []rules.Match {
+	var labels []string
+	if issue != nil {
+		for _, l := range issue.Labels {
+			labels = append(labels, l.GetName())
+		}
+	}
+
+	result := rules.Apply(rs, labels)
+	if result.IssueType != "" {
+		ji.Fields.Type.Name = result.IssueType
+	}
+	if result.Priority != "" {
+		ji.Fields.Priority = &gojira.Priority{Name: result.Priority}
+	}
+	ji.Fields.Labels = result.Labels
+	for _, c := range result.Components {
+		ji.Fields.Components = append(ji.Fields.Components, &gojira.Component{Name: c})
+	}
+	return result.Matches
+}
+
+// appendLabel adds label to labels unless it is already there.
+func appendLabel(labels []string, label string) []string {
+	for _, l := range labels {
+		if l == label {
+			return labels
+		}
+	}
+	return append(labels, label)
+}
+
+// commentBody attributes the Github comment to its author and links back to
+// the original.
+func commentBody(comment *github.IssueComment) string {
+	login := comment.GetUser().GetLogin()
+	return fmt.Sprintf("[%s|https://github.com/%s] commented on %s ([original comment|%s]):\n\n%s",
+		login, login, comment.GetCreatedAt().UTC().Format("2006-01-02 15:04 MST"),
+		comment.GetHTMLURL(), markup.ToJira(comment.GetBody()))
+}
+
+// handleExisting applies the configured ExistingAction to a Github issue that
+// has already been cloned to Jira.
+func handleExisting(jiraClient *gojira.Client, config *ClonerConfig, issue *github.Issue,
+	existing *gojira.Issue, ji *gojira.Issue) (*gojira.Issue, Outcome, error) {
+
+	switch config.onExisting {
+	case ExistingReport:
+		return existing, Skipped, &AlreadyClonedError{Key: existing.Key, URL: getWebURL(issue.GetURL())}
+	case ExistingUpdate:
+		// keep the description pointing at the attachments mirrored when
+		// the issue was cloned
+		description := ji.Fields.Description
+		if urls := attachmentURLs(description); len(urls) > 0 {
+			description = reattach(jiraClient, config, existing.Key, description, urls)
+		}
+		update := gojira.Issue{
+			Fields: &gojira.IssueFields{
+				Summary:     ji.Fields.Summary,
+				Description: description,
+			},
+		}
+		if config.dryRun {
+			fmt.Printf("Issue #%d already cloned to %s; would update summary and description\n",
+				issue.GetNumber(), existing.Key)
+			if err := printPayload("PUT /rest/api/2/issue/"+existing.Key, &update); err != nil {
+				return existing, Failed, err
+			}
+			if config.plan != nil {
+				weburl := getWebURL(issue.GetURL())
+				config.plan.Add(PlannedIssue{
+					Project:    webURLProject(weburl),
+					Number:     issue.GetNumber(),
+					URL:        weburl,
+					Key:        existing.Key,
+					Payload:    &update,
+					RemoteLink: config.remoteLink(issue),
+				})
+			}
+			return existing, Planned, nil
+		}
+		update.Key = existing.Key
+		if _, resp, err := jiraClient.Issue.Update(&update); err != nil {
+			return existing, Failed, responseError(resp, err)
+		}
+		existing.Fields.Summary = ji.Fields.Summary
+		existing.Fields.Description = description
+		fmt.Printf("Issue #%d already cloned to %s; updated summary and description\n",
+			issue.GetNumber(), existing.Key)
+		if link := config.remoteLink(issue); link != nil {
+			addRemoteLink(jiraClient, existing.Key, link)
+		}
+		return existing, Updated, nil
+	default:
+		fmt.Printf("Issue #%d already cloned to %s; skipping\n", issue.GetNumber(),
+			BrowseURL(config.jiraURL, existing.Key))
+		// the remote link follows the Github issue even when the Jira
+		// issue is left alone
+		if link := config.remoteLink(issue); link != nil && !config.dryRun {
+			addRemoteLink(jiraClient, existing.Key, link)
+		}
+	}
+	return existing, Skipped, nil
 }
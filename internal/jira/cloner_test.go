@@ -15,20 +15,55 @@
 package jira
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	gojira "github.com/andygrunwald/go-jira"
 	"github.com/google/go-github/v47/github"
 	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/rules"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+// searchResult builds the body the Jira search endpoint returns for the
+// given issues.
+func searchResult(issues ...gojira.Issue) map[string]interface{} {
+	if issues == nil {
+		issues = []gojira.Issue{}
+	}
+	return map[string]interface{}{
+		"startAt":    0,
+		"maxResults": 50,
+		"total":      len(issues),
+		"issues":     issues,
+	}
+}
+
+// fakeAnnotator records the Github issues it is asked to annotate.
+type fakeAnnotator struct {
+	annotated []string
+	err       error
+}
+
+func (f *fakeAnnotator) Describe(project string, number int) string {
+	return fmt.Sprintf("label %s#%d", project, number)
+}
+
+func (f *fakeAnnotator) Annotate(project string, number int, key string) error {
+	f.annotated = append(f.annotated, fmt.Sprintf("%s#%d %s", project, number, key))
+	return f.err
+}
+
 var _ = Describe("Cloner", func() {
 
 	// Test out the ClonerConfig struct and its methods
@@ -63,6 +98,16 @@ var _ = Describe("Cloner", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(token).To(Equal(""))
 			})
+			It("should read the token from the configured variable", func() {
+				err := os.Setenv("GH2JIRA_TEST_TOKEN", "from-other-env")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Unsetenv("GH2JIRA_TEST_TOKEN")
+
+				options.tokenEnv = "GH2JIRA_TEST_TOKEN"
+				token, err := options.getToken()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("from-other-env"))
+			})
 		})
 	})
 
@@ -104,6 +149,71 @@ var _ = Describe("Cloner", func() {
 				Expect(options.project).To(Equal("OSDK"))
 			})
 		})
+		Describe("WithTokenEnv", func() {
+			It("should set the token environment variable", func() {
+				opt := WithTokenEnv("JIRA_PAT")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.tokenEnv).To(Equal("JIRA_PAT"))
+			})
+		})
+		Describe("WithIssueType", func() {
+			It("should set the issue type", func() {
+				opt := WithIssueType("Bug")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.issueType).To(Equal("Bug"))
+			})
+		})
+		Describe("WithComments", func() {
+			It("should set the comments", func() {
+				comments := []*github.IssueComment{{Body: github.String("first")}}
+				opt := WithComments(comments)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.comments).To(Equal(comments))
+			})
+		})
+		Describe("WithPullRequest", func() {
+			It("should set the pull request and its reviews", func() {
+				pr := &github.PullRequest{Number: github.Int(124)}
+				reviews := []*github.PullRequestReview{{State: github.String("APPROVED")}}
+				Expect(WithPullRequest(pr, reviews)(&options)).To(Succeed())
+				Expect(options.pr).To(Equal(pr))
+				Expect(options.reviews).To(Equal(reviews))
+			})
+		})
+		Describe("WithReport", func() {
+			It("should set the report", func() {
+				report := &Report{}
+				opt := WithReport(report)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.report).To(BeIdenticalTo(report))
+			})
+		})
+		Describe("WithEpic", func() {
+			It("should set the epic", func() {
+				opt := WithEpic(&Epic{Key: "OSDK-1"})
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.epic.Key).To(Equal("OSDK-1"))
+			})
+		})
+		Describe("WithRules", func() {
+			It("should set the rules", func() {
+				rs := []rules.Rule{{Label: "kind/bug", IssueType: "Bug"}}
+				opt := WithRules(rs)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.rules).To(Equal(rs))
+			})
+			It("should return an error for an invalid rule", func() {
+				opt := WithRules([]rules.Rule{{Label: "kind/bug"}})
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
 		Describe("WithJiraURL", func() {
 			It("should set the jira url", func() {
 				url := "https://issues.jira.com"
@@ -113,6 +223,47 @@ var _ = Describe("Cloner", func() {
 				Expect(options.jiraURL).To(Equal(url))
 			})
 		})
+		Describe("WithOnExisting", func() {
+			It("should set the existing action", func() {
+				opt := WithOnExisting(ExistingUpdate)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.onExisting).To(Equal(ExistingUpdate))
+			})
+			It("should return an error for an unknown action", func() {
+				opt := WithOnExisting("clobber")
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+				Expect(options.onExisting).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("BrowseURL", func() {
+		It("should append the issue key to the browse path", func() {
+			Expect(BrowseURL("https://issues.redhat.com", "OSDK-1")).
+				To(Equal("https://issues.redhat.com/browse/OSDK-1"))
+		})
+		It("should not double up a trailing slash", func() {
+			Expect(BrowseURL("http://localhost:8080/", "TEST-7")).
+				To(Equal("http://localhost:8080/browse/TEST-7"))
+		})
+	})
+
+	Describe("commentBody", func() {
+		It("should attribute the comment and link to the original", func() {
+			created := time.Date(2022, 9, 15, 13, 4, 0, 0, time.UTC)
+			comment := &github.IssueComment{
+				Body:      github.String("looks like a bug to me"),
+				User:      &github.User{Login: github.String("octocat")},
+				CreatedAt: &created,
+				HTMLURL:   github.String("https://github.com/foo/bar/issues/123#issuecomment-1"),
+			}
+			Expect(commentBody(comment)).To(Equal(
+				"[octocat|https://github.com/octocat] commented on 2022-09-15 13:04 UTC " +
+					"([original comment|https://github.com/foo/bar/issues/123#issuecomment-1]):" +
+					"\n\nlooks like a bug to me"))
+		})
 	})
 
 	Describe("getWebURL", func() {
@@ -163,6 +314,7 @@ var _ = Describe("Cloner", func() {
 		})
 		It("should print out issue when dryRun is true", func() {
 			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
 				jmock.WithRequestMatch(jmock.PostIssue),
 			)
 
@@ -241,6 +393,7 @@ var _ = Describe("Cloner", func() {
 			}
 
 			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
 				jmock.WithRequestMatch(jmock.PostIssue, expectedissue),
 			)
 
@@ -254,17 +407,696 @@ var _ = Describe("Cloner", func() {
 				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
 			}
 
+			// Capture stdout to verify the browse link
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+
 			// Test the clone function
 			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
 				WithDryRun(false),
 				WithJiraURL("http://localhost"),
 			)
+			w.Close()
+			stdout, _ := io.ReadAll(r)
+
 			Expect(err).NotTo(HaveOccurred())
+			Expect(string(stdout)).To(ContainSubstring("see http://localhost/browse/"))
 			Expect(jissue).NotTo(BeNil())
 			Expect(jissue.Fields.Description).To(Equal(expectedissue.Fields.Description))
 			Expect(jissue.Fields.Type).To(Equal(expectedissue.Fields.Type))
 			Expect(jissue.Fields.Project).To(Equal(expectedissue.Fields.Project))
 			Expect(jissue.Fields.Summary).To(Equal(expectedissue.Fields.Summary))
 		})
+		It("should copy the comments to the new jira issue", func() {
+			var posted []string
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment gojira.Comment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						posted = append(posted, comment.Body)
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+			)
+
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+			comments := []*github.IssueComment{
+				{Body: github.String("first"), User: &github.User{Login: github.String("a")}},
+				{Body: github.String("second"), User: &github.User{Login: github.String("b")}},
+			}
+
+			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithComments(comments),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-7"))
+			Expect(posted).To(HaveLen(2))
+			Expect(posted[0]).To(HaveSuffix("first"))
+			Expect(posted[1]).To(HaveSuffix("second"))
+		})
+		It("should keep the new jira issue when a comment can not be copied", func() {
+			var (
+				posted []string
+				link   gojira.RemoteLink
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment gojira.Comment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						if strings.HasSuffix(comment.Body, "first") {
+							jmock.WriteError(w, http.StatusBadRequest, "comment too long")
+							return
+						}
+						posted = append(posted, comment.Body)
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						w.Write(jmock.MustMarshal(link))
+					}),
+				),
+			)
+
+			ghissue := &github.Issue{
+				Number:  github.Int(123),
+				Title:   github.String("Issue 1"),
+				URL:     github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				HTMLURL: github.String("https://github.com/foo/bar/issues/123"),
+			}
+			comments := []*github.IssueComment{
+				{Body: github.String("first"), User: &github.User{Login: github.String("a")}},
+				{Body: github.String("second"), User: &github.User{Login: github.String("b")}},
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			report := &Report{}
+			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithComments(comments),
+				WithReport(report),
+			)
+			w.Close()
+			out, _ := io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-7"))
+			Expect(string(out)).To(ContainSubstring("Warning: unable to copy comment"))
+			Expect(string(out)).To(ContainSubstring("Copied 1 of 2 comments"))
+			Expect(posted).To(HaveLen(1))
+			Expect(posted[0]).To(HaveSuffix("second"))
+			Expect(link.GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+			Expect(report.Entries).To(HaveLen(1))
+			Expect(report.Entries[0].Outcome).To(Equal(Created))
+		})
+		It("should convert the Markdown body to Jira markup", func() {
+			var created gojira.Issue
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(jmock.MustMarshal(created))
+					}),
+				),
+			)
+
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body:   github.String("## Bug Report\n\nRun `make bundle`"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Fields.Description).To(Equal("h2. Bug Report\n\nRun {{make bundle}}" +
+				"\n\nUpstream Github issue: https://github.com/foo/bar/issues/123\n"))
+		})
+		It("should set the fields chosen by the label rules", func() {
+			var created gojira.Issue
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(jmock.MustMarshal(created))
+					}),
+				),
+			)
+
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				Labels: []*github.Label{
+					{Name: github.String("kind/bug")},
+					{Name: github.String("priority/important-soon")},
+					{Name: github.String("area/helm")},
+				},
+			}
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithRules([]rules.Rule{
+					{Label: "kind/bug", IssueType: "Bug"},
+					{Label: "priority/important-soon", Priority: "Urgent"},
+					{Label: "area/*", Labels: []string{"upstream"}, Components: []string{"Helm"}},
+				}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Fields.Type.Name).To(Equal("Bug"))
+			Expect(created.Fields.Priority.Name).To(Equal("Urgent"))
+			Expect(created.Fields.Labels).To(Equal([]string{"upstream"}))
+			Expect(created.Fields.Components).To(HaveLen(1))
+			Expect(created.Fields.Components[0].Name).To(Equal("Helm"))
+		})
+		It("should clone a pull request with its metadata", func() {
+			var created gojira.Issue
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(jmock.MustMarshal(created))
+					}),
+				),
+			)
+
+			ghissue := &github.Issue{
+				Number:           github.Int(124),
+				Title:            github.String("Fix the bundle"),
+				Body:             github.String("Fixes #123"),
+				URL:              github.String("https://api.github.com/repos/foo/bar/issues/124"),
+				PullRequestLinks: &github.PullRequestLinks{},
+			}
+			pr := &github.PullRequest{
+				Number:  github.Int(124),
+				State:   github.String("open"),
+				Body:    github.String("Fixes #123"),
+				HTMLURL: github.String("https://github.com/foo/bar/pull/124"),
+				Head:    &github.PullRequestBranch{Label: github.String("jdoe:fix-bundle")},
+				Base: &github.PullRequestBranch{
+					Ref:  github.String("master"),
+					Repo: &github.Repository{FullName: github.String("foo/bar")},
+				},
+			}
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithPullRequest(pr, nil),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Fields.Summary).To(Equal("[UPSTREAM PR] Fix the bundle #124"))
+			Expect(created.Fields.Labels).To(Equal([]string{PullRequestLabel}))
+			Expect(created.Fields.Description).To(Equal(
+				"||Pull request|[foo/bar#124|https://github.com/foo/bar/pull/124]|\n" +
+					"||Branches|{{jdoe:fix-bundle}} into {{master}}|\n" +
+					"||Status|open|\n" +
+					"||Mergeable|unknown|\n" +
+					"||Reviews|none|\n" +
+					"||Linked issues|[#123|https://github.com/foo/bar/issues/123]|\n" +
+					"\nFixes #123" +
+					"\n\nUpstream Github issue: https://github.com/foo/bar/issues/124\n"))
+		})
+		Context("with an epic", func() {
+			var (
+				ghissue *github.Issue
+				created map[string]interface{}
+				epic    gojira.Issue
+			)
+			BeforeEach(func() {
+				created = nil
+				ghissue = &github.Issue{
+					Number: github.Int(123),
+					Title:  github.String("Issue 1"),
+					URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				}
+				epic = gojira.Issue{
+					Key: "OSDK-1",
+					Fields: &gojira.IssueFields{
+						Type:    gojira.IssueType{Name: "Epic"},
+						Summary: "The epic",
+					},
+				}
+			})
+			client := func(epicResponse interface{}, fields []gojira.Field) *http.Client {
+				return jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetIssueByKey, epicResponse),
+					jmock.WithRequestMatch(jmock.GetFields, fields),
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+					jmock.WithRequestMatchHandler(
+						jmock.PostIssue,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+							w.Write(jmock.MustMarshal(gojira.Issue{Key: "OSDK-2"}))
+						}),
+					),
+				)
+			}
+			It("should use the Epic Link field when there is one", func() {
+				mockedHTTPClient := client(epic, []gojira.Field{
+					{ID: "summary", Name: "Summary"},
+					{ID: "customfield_12311140", Name: "Epic Link", Custom: true,
+						Schema: gojira.FieldSchema{Custom: epicLinkSchema}},
+				})
+				resolved, err := ResolveEpic("OSDK-1", WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(&Epic{Key: "OSDK-1", LinkField: "customfield_12311140"}))
+
+				_, err = Clone(ghissue, WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+					WithEpic(resolved),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				fields := created["fields"].(map[string]interface{})
+				Expect(fields).To(HaveKeyWithValue("customfield_12311140", "OSDK-1"))
+				Expect(fields).NotTo(HaveKey("parent"))
+			})
+			It("should use the parent field when there is no Epic Link field", func() {
+				mockedHTTPClient := client(epic, []gojira.Field{
+					{ID: "summary", Name: "Summary"},
+				})
+				resolved, err := ResolveEpic("OSDK-1", WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved.LinkField).To(BeEmpty())
+
+				_, err = Clone(ghissue, WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+					WithEpic(resolved),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				fields := created["fields"].(map[string]interface{})
+				Expect(fields).To(HaveKeyWithValue("parent", HaveKeyWithValue("key", "OSDK-1")))
+			})
+			It("should not look the epic up again for each issue", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+					jmock.WithRequestMatchHandler(
+						jmock.PostIssue,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+							w.Write(jmock.MustMarshal(gojira.Issue{Key: "OSDK-2"}))
+						}),
+					),
+				)
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+					WithEpic(&Epic{Key: "OSDK-1", LinkField: "customfield_12311140"}),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				fields := created["fields"].(map[string]interface{})
+				Expect(fields).To(HaveKeyWithValue("customfield_12311140", "OSDK-1"))
+			})
+			It("should return an error if the epic does not exist", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatchHandler(
+						jmock.GetIssueByKey,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							jmock.WriteError(w, http.StatusNotFound, "Issue Does Not Exist")
+						}),
+					),
+				)
+				_, err := ResolveEpic("OSDK-404", WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("OSDK-404"))
+			})
+			It("should refuse an Epic Link to an issue that is not an epic", func() {
+				epic.Fields.Type.Name = "Story"
+				mockedHTTPClient := client(epic, []gojira.Field{
+					{ID: "customfield_12311140", Name: "Epic Link", Custom: true,
+						Schema: gojira.FieldSchema{Custom: epicLinkSchema}},
+				})
+				_, err := ResolveEpic("OSDK-1", WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("OSDK-1 is a Story, not an Epic"))
+			})
+		})
+		Context("with a link store", func() {
+			var (
+				dir     string
+				store   links.Store
+				ghissue *github.Issue
+			)
+			BeforeEach(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "gh2jira-links")
+				Expect(err).NotTo(HaveOccurred())
+				store, err = links.Open("json", filepath.Join(dir, "links.json"))
+				Expect(err).NotTo(HaveOccurred())
+				ghissue = &github.Issue{
+					Number: github.Int(123),
+					Title:  github.String("Issue 1"),
+					URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				}
+			})
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+			It("should record the link of a created issue", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+					jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				)
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithLinks(store),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				found, err := store.Find("foo/bar#123", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(HaveLen(1))
+				Expect(found[0].Jira).To(Equal("OSDK-7"))
+			})
+			It("should record the link of an issue cloned before", func() {
+				existing := gojira.Issue{
+					Key: "OSDK-42",
+					Fields: &gojira.IssueFields{
+						Description: "Upstream Github issue: https://github.com/foo/bar/issues/123\n",
+					},
+				}
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				)
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithLinks(store),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store.Find("foo/bar#123", "OSDK-42")).To(HaveLen(1))
+			})
+			It("should not record anything in dry run mode", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				)
+
+				r, w, _ := os.Pipe()
+				tmp := os.Stdout
+				defer func() {
+					os.Stdout = tmp
+				}()
+				os.Stdout = w
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithDryRun(true),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithLinks(store),
+				)
+				w.Close()
+				_, _ = io.ReadAll(r)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(store.Find("", "")).To(BeEmpty())
+			})
+		})
+		Context("with an annotator", func() {
+			var (
+				annotator *fakeAnnotator
+				ghissue   *github.Issue
+			)
+			BeforeEach(func() {
+				annotator = &fakeAnnotator{}
+				ghissue = &github.Issue{
+					Number: github.Int(123),
+					Title:  github.String("Issue 1"),
+					URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				}
+			})
+			It("should annotate the Github issue of a created issue", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+					jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				)
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+					WithAnnotator(annotator),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(annotator.annotated).To(Equal([]string{"foo/bar#123 OSDK-7"}))
+			})
+			It("should only warn when the annotation fails", func() {
+				annotator.err = errors.New("forbidden")
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+					jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				)
+
+				r, w, _ := os.Pipe()
+				tmp := os.Stdout
+				defer func() {
+					os.Stdout = tmp
+				}()
+				os.Stdout = w
+				created, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+					WithAnnotator(annotator),
+				)
+				w.Close()
+				out, _ := io.ReadAll(r)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created.Key).To(Equal("OSDK-7"))
+				Expect(string(out)).To(ContainSubstring("Warning: unable to annotate foo/bar#123: forbidden"))
+			})
+			It("should leave the Github issue of an issue cloned before alone", func() {
+				existing := gojira.Issue{
+					Key: "OSDK-42",
+					Fields: &gojira.IssueFields{
+						Description: "Upstream Github issue: https://github.com/foo/bar/issues/123\n",
+					},
+				}
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				)
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+					WithAnnotator(annotator),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(annotator.annotated).To(BeEmpty())
+			})
+			It("should only describe the annotation in dry run mode", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				)
+
+				r, w, _ := os.Pipe()
+				tmp := os.Stdout
+				defer func() {
+					os.Stdout = tmp
+				}()
+				os.Stdout = w
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithDryRun(true),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithAnnotator(annotator),
+				)
+				w.Close()
+				out, _ := io.ReadAll(r)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(out)).To(ContainSubstring("Github: label foo/bar#123\n"))
+				Expect(annotator.annotated).To(BeEmpty())
+			})
+		})
+		Context("when the issue was already cloned", func() {
+			var (
+				ghissue  *github.Issue
+				existing gojira.Issue
+			)
+			BeforeEach(func() {
+				ghissue = &github.Issue{
+					Number: github.Int(123),
+					Title:  github.String("Issue 1"),
+					Body:   github.String("new body"),
+					URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+				}
+				existing = gojira.Issue{
+					Key: "OSDK-42",
+					Fields: &gojira.IssueFields{
+						Summary: "[UPSTREAM] Issue 1 #123",
+						Description: "old body\n\nUpstream Github issue: " +
+							"https://github.com/foo/bar/issues/123\n",
+					},
+				}
+			})
+			It("should find a clone whose description was edited by its remote link", func() {
+				existing.Fields.Description = "rewritten by hand"
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatchHandler(
+						jmock.GetSearch,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							Expect(r.URL.Query().Get("jql")).To(ContainSubstring(
+								`issue in issuesWithRemoteLinksByGlobalId("https://github.com/foo/bar/issues/123")`))
+							w.Write(jmock.MustMarshal(searchResult(existing)))
+						}),
+					),
+					jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{
+						{GlobalID: "https://github.com/foo/bar/issues/123"},
+					}),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+			})
+			It("should find a clone whose description was edited in the link store", func() {
+				dir, err := os.MkdirTemp("", "gh2jira-links")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(dir)
+				store, err := links.Open("json", filepath.Join(dir, "links.json"))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = store.Add(links.Link{Github: "foo/bar#123", Jira: "OSDK-42"})
+				Expect(err).NotTo(HaveOccurred())
+
+				existing.Fields.Description = "rewritten by hand"
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetIssueByKey, existing),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+					WithLinks(store),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+			})
+			It("should search the descriptions only when Jira lacks the remote link search", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatchHandler(
+						jmock.GetSearch,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							if strings.Contains(r.URL.Query().Get("jql"), "issuesWithRemoteLinksByGlobalId") {
+								jmock.WriteError(w, http.StatusBadRequest, "unknown function")
+								return
+							}
+							w.Write(jmock.MustMarshal(searchResult(existing)))
+						}),
+					),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithRemoteLink(false),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+			})
+			It("should skip creating a new issue by default", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+				Expect(jissue.Fields.Description).To(HavePrefix("old body"))
+			})
+			It("should ignore matches for a different issue", func() {
+				other := existing
+				other.Key = "OSDK-43"
+				other.Fields = &gojira.IssueFields{
+					Description: "Upstream Github issue: https://github.com/foo/bar/issues/1234\n",
+				}
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(other)),
+					jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-44"}),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-44"))
+			})
+			It("should return an AlreadyClonedError when reporting", func() {
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithOnExisting(ExistingReport),
+				)
+				Expect(err).To(HaveOccurred())
+				var ace *AlreadyClonedError
+				Expect(errors.As(err, &ace)).To(BeTrue())
+				Expect(ace.Key).To(Equal("OSDK-42"))
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+			})
+			It("should update the existing issue when asked", func() {
+				var body []byte
+				mockedHTTPClient := jmock.NewMockedHTTPClient(
+					jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+					jmock.WithRequestMatchHandler(
+						jmock.PutIssueByKey,
+						http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							body, _ = io.ReadAll(r.Body)
+							w.WriteHeader(http.StatusNoContent)
+						}),
+					),
+				)
+				jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithProject("OSDK"),
+					WithJiraURL("http://localhost"),
+					WithOnExisting(ExistingUpdate),
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(jissue.Key).To(Equal("OSDK-42"))
+				Expect(jissue.Fields.Description).To(HavePrefix("new body"))
+				Expect(string(body)).To(ContainSubstring("new body"))
+			})
+		})
 	})
 })
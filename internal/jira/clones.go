@@ -0,0 +1,217 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+// upstreamRE matches the upstream link Clone writes into the description.
+var upstreamRE = regexp.MustCompile(`Upstream Github issue: https://github\.com/([^/\s]+)/([^/\s]+)/issues/(\d+)`)
+
+// ClonedIssue is a Jira issue created by Clone along with the Github issue it
+// was cloned from.
+type ClonedIssue struct {
+	Key     string
+	Status  string
+	Updated time.Time
+
+	Org    string
+	Repo   string
+	Number int
+}
+
+// GithubProject returns the ORG/REPO the issue was cloned from.
+func (c ClonedIssue) GithubProject() string {
+	return fmt.Sprintf("%s/%s", c.Org, c.Repo)
+}
+
+// GithubURL returns the web URL of the Github issue.
+func (c ClonedIssue) GithubURL() string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%d", c.Org, c.Repo, c.Number)
+}
+
+// parseUpstream pulls the upstream Github issue out of a cloned description.
+func parseUpstream(description string) (string, string, int, bool) {
+	m := upstreamRE.FindStringSubmatch(description)
+	if m == nil {
+		return "", "", 0, false
+	}
+	num, err := strconv.Atoi(m[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return m[1], m[2], num, true
+}
+
+// UpstreamURL returns the web URL of the Github issue the description links
+// to, if any.
+func UpstreamURL(description string) (string, bool) {
+	org, repo, num, ok := parseUpstream(description)
+	if !ok {
+		return "", false
+	}
+	return ClonedIssue{Org: org, Repo: repo, Number: num}.GithubURL(), true
+}
+
+// FindClones returns every issue in the Jira project that links back to an
+// upstream Github issue.
+func FindClones(opts ...Option) ([]ClonedIssue, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	jql := fmt.Sprintf("project = %q AND description ~ %q", config.project,
+		`"Upstream Github issue"`)
+
+	var clones []ClonedIssue
+	err = jiraClient.Issue.SearchPages(jql, &gojira.SearchOptions{
+		MaxResults: 50,
+		Fields:     []string{"description", "status", "updated"},
+	}, func(issue gojira.Issue) error {
+		if issue.Fields == nil {
+			return nil
+		}
+		org, repo, num, ok := parseUpstream(issue.Fields.Description)
+		if !ok {
+			return nil
+		}
+		clone := ClonedIssue{
+			Key:     issue.Key,
+			Updated: time.Time(issue.Fields.Updated),
+			Org:     org,
+			Repo:    repo,
+			Number:  num,
+		}
+		if issue.Fields.Status != nil {
+			clone.Status = issue.Fields.Status.Name
+		}
+		clones = append(clones, clone)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clones, nil
+}
+
+// FindLinked returns the Jira issues of the links in the configured project.
+func FindLinked(ls []links.Link, opts ...Option) ([]ClonedIssue, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var linked []ClonedIssue
+	for _, l := range ls {
+		if config.project != "" && !strings.HasPrefix(strings.ToUpper(l.Jira),
+			strings.ToUpper(config.project)+"-") {
+			continue
+		}
+		project, num, err := links.SplitGithubRef(l.Github)
+		if err != nil {
+			return nil, err
+		}
+		org, repo, _ := strings.Cut(project, "/")
+
+		issue, resp, err := jiraClient.Issue.Get(l.Jira, &gojira.GetQueryOptions{
+			Fields: "status,updated",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s linked to %s: %w", l.Jira, l.Github,
+				responseError(resp, err))
+		}
+		clone := ClonedIssue{
+			Key:    l.Jira,
+			Org:    org,
+			Repo:   repo,
+			Number: num,
+		}
+		if issue.Fields != nil {
+			clone.Updated = time.Time(issue.Fields.Updated)
+			if issue.Fields.Status != nil {
+				clone.Status = issue.Fields.Status.Name
+			}
+		}
+		linked = append(linked, clone)
+	}
+	return linked, nil
+}
+
+// TransitionIssue moves the Jira issue key to a status or by transition name.
+func TransitionIssue(key string, status string, opts ...Option) error {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return err
+	}
+
+	transitions, resp, err := jiraClient.Issue.GetTransitions(key)
+	if err != nil {
+		return responseError(resp, err)
+	}
+
+	var available []string
+	for _, t := range transitions {
+		if strings.EqualFold(t.To.Name, status) || strings.EqualFold(t.Name, status) {
+			resp, err := jiraClient.Issue.DoTransition(key, t.ID)
+			return responseError(resp, err)
+		}
+		available = append(available, t.To.Name)
+	}
+	return fmt.Errorf("%s has no transition to %q, available: %s", key, status,
+		strings.Join(available, ", "))
+}
@@ -0,0 +1,167 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/links"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clones", func() {
+	var (
+		originalToken string
+	)
+	BeforeEach(func() {
+		originalToken = os.Getenv("JIRA_TOKEN")
+		err := os.Setenv("JIRA_TOKEN", "blah-blah-blah")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		err := os.Setenv("JIRA_TOKEN", originalToken)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("parseUpstream", func() {
+		It("should find the upstream issue in a cloned description", func() {
+			org, repo, num, ok := parseUpstream("body\n\nUpstream Github issue: " +
+				"https://github.com/operator-framework/operator-sdk/issues/3447\n")
+			Expect(ok).To(BeTrue())
+			Expect(org).To(Equal("operator-framework"))
+			Expect(repo).To(Equal("operator-sdk"))
+			Expect(num).To(Equal(3447))
+		})
+		It("should return false if there is no upstream link", func() {
+			_, _, _, ok := parseUpstream("just a jira issue")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("UpstreamURL", func() {
+		It("should return the URL of the upstream issue", func() {
+			url, ok := UpstreamURL("body\n\nUpstream Github issue: " +
+				"https://github.com/operator-framework/operator-sdk/issues/3447\n")
+			Expect(ok).To(BeTrue())
+			Expect(url).To(Equal("https://github.com/operator-framework/operator-sdk/issues/3447"))
+		})
+	})
+
+	Describe("FindClones", func() {
+		It("should return only issues with an upstream link", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult(
+					gojira.Issue{
+						Key: "OSDK-1",
+						Fields: &gojira.IssueFields{
+							Description: "Upstream Github issue: https://github.com/foo/bar/issues/1\n",
+							Status:      &gojira.Status{Name: "New"},
+						},
+					},
+					gojira.Issue{
+						Key: "OSDK-2",
+						Fields: &gojira.IssueFields{
+							Description: "mentions the Upstream Github issue in passing",
+						},
+					},
+				)),
+			)
+			clones, err := FindClones(WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithProject("OSDK"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clones).To(HaveLen(1))
+			Expect(clones[0].Key).To(Equal("OSDK-1"))
+			Expect(clones[0].Status).To(Equal("New"))
+			Expect(clones[0].GithubProject()).To(Equal("foo/bar"))
+			Expect(clones[0].GithubURL()).To(Equal("https://github.com/foo/bar/issues/1"))
+		})
+	})
+
+	Describe("FindLinked", func() {
+		It("should return the linked issues of the project", func() {
+			updated := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key: "OSDK-3",
+					Fields: &gojira.IssueFields{
+						Status:  &gojira.Status{Name: "In Progress"},
+						Updated: gojira.Time(updated),
+					},
+				}),
+			)
+			linked, err := FindLinked([]links.Link{
+				{Github: "foo/bar#12", Jira: "OSDK-3"},
+				{Github: "foo/bar#13", Jira: "OLM-4"},
+			}, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(linked).To(HaveLen(1))
+			Expect(linked[0].Key).To(Equal("OSDK-3"))
+			Expect(linked[0].Status).To(Equal("In Progress"))
+			Expect(linked[0].Updated).To(BeTemporally("==", updated))
+			Expect(linked[0].GithubURL()).To(Equal("https://github.com/foo/bar/issues/12"))
+		})
+	})
+
+	Describe("TransitionIssue", func() {
+		var (
+			transitionedTo   string
+			mockedHTTPClient *http.Client
+		)
+		BeforeEach(func() {
+			transitionedTo = ""
+			mockedHTTPClient = jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueTransitionsByKey, map[string]interface{}{
+					"transitions": []gojira.Transition{
+						{ID: "11", Name: "Start", To: gojira.Status{Name: "In Progress"}},
+						{ID: "61", Name: "Close Issue", To: gojira.Status{Name: "Closed"}},
+					},
+				}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueTransitionsByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var payload gojira.CreateTransitionPayload
+						Expect(json.NewDecoder(r.Body).Decode(&payload)).To(Succeed())
+						transitionedTo = payload.Transition.ID
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+		})
+		It("should use the transition that ends in the given status", func() {
+			err := TransitionIssue("OSDK-1", "closed", WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transitionedTo).To(Equal("61"))
+		})
+		It("should return an error if no transition ends in the status", func() {
+			err := TransitionIssue("OSDK-1", "Verified", WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("In Progress, Closed"))
+			Expect(transitionedTo).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,120 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// maxBody is how much of a response body that is not JSON ends up in a
+// ResponseError.
+const maxBody = 512
+
+// ResponseError is a failed Jira request along with what Jira said about it.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	// Messages are the errorMessages of the response followed by the field
+	// errors as "field: message", sorted by field.
+	Messages []string
+	// Body is the start of the response body when it was not a Jira error.
+	Body string
+	Err  error
+}
+
+func (e *ResponseError) Error() string {
+	switch {
+	case len(e.Messages) > 0:
+		return fmt.Sprintf("jira returned %s: %s", e.Status, strings.Join(e.Messages, "; "))
+	case e.Body != "":
+		return fmt.Sprintf("jira returned %s: %s", e.Status, e.Body)
+	}
+	return fmt.Sprintf("jira returned %s", e.Status)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// responseError adds the body of Jira's response to the error of a go-jira
+// call, which only carries the status code. Calls that read the body
+// themselves return a *gojira.Error holding the Jira messages, which are used
+// instead. It returns err as is when there is no response to read.
+func responseError(resp *gojira.Response, err error) error {
+	if err == nil || resp == nil || resp.Response == nil {
+		return err
+	}
+
+	rerr := &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status, Err: err}
+	if rerr.Status == "" {
+		rerr.Status = fmt.Sprintf("%d", resp.StatusCode)
+	}
+
+	var jerr *gojira.Error
+	if errors.As(err, &jerr) {
+		rerr.Messages = jiraMessages(jerr.ErrorMessages, jerr.Errors)
+		if len(rerr.Messages) == 0 {
+			return err
+		}
+		return rerr
+	}
+	if resp.Body == nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, rdErr := io.ReadAll(resp.Body)
+	if rdErr != nil {
+		return err
+	}
+
+	var jiraBody struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if json.Unmarshal(body, &jiraBody) == nil {
+		rerr.Messages = jiraMessages(jiraBody.ErrorMessages, jiraBody.Errors)
+	}
+	if len(rerr.Messages) == 0 {
+		b := strings.TrimSpace(string(body))
+		if len(b) > maxBody {
+			b = b[:maxBody] + "..."
+		}
+		rerr.Body = b
+	}
+	return rerr
+}
+
+// jiraMessages returns the errorMessages of a Jira error followed by its field
+// errors as "field: message", sorted by field.
+func jiraMessages(errorMessages []string, fieldErrors map[string]string) []string {
+	messages := append([]string(nil), errorMessages...)
+	fields := make([]string, 0, len(fieldErrors))
+	for f := range fieldErrors {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", f, fieldErrors[f]))
+	}
+	return messages
+}
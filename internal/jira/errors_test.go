@@ -0,0 +1,123 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+func jiraResponse(status int, body string) *gojira.Response {
+	return &gojira.Response{Response: &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}}
+}
+
+var _ = Describe("responseError", func() {
+	httpErr := errors.New("request failed. Please analyze the request body for more details. Status code: 400")
+
+	It("should pass nil through", func() {
+		Expect(responseError(jiraResponse(200, ""), nil)).To(BeNil())
+	})
+	It("should return the error as is without a response", func() {
+		Expect(responseError(nil, httpErr)).To(Equal(httpErr))
+	})
+	It("should include the Jira error messages", func() {
+		err := responseError(jiraResponse(400, `{"errorMessages":["Bad things"],`+
+			`"errors":{"summary":"You must specify a summary","components":"Component name 'X' is not valid"}}`),
+			httpErr)
+		Expect(err).To(MatchError("jira returned 400 Bad Request: Bad things; " +
+			"components: Component name 'X' is not valid; summary: You must specify a summary"))
+
+		var rerr *ResponseError
+		Expect(errors.As(err, &rerr)).To(BeTrue())
+		Expect(rerr.StatusCode).To(Equal(400))
+		Expect(errors.Is(err, httpErr)).To(BeTrue())
+	})
+	It("should include a body that is not a Jira error", func() {
+		err := responseError(jiraResponse(502, "<html>Bad Gateway</html>\n"), httpErr)
+		Expect(err).To(MatchError("jira returned 502 Bad Gateway: <html>Bad Gateway</html>"))
+	})
+	It("should cut a long body short", func() {
+		err := responseError(jiraResponse(500, strings.Repeat("x", 2*maxBody)), httpErr)
+		Expect(err.Error()).To(HaveSuffix(strings.Repeat("x", 10) + "..."))
+		Expect(len(err.Error())).To(BeNumerically("<", maxBody+50))
+	})
+	It("should use the Jira error messages go-jira already read", func() {
+		jerr := &gojira.Error{
+			HTTPError:     httpErr,
+			ErrorMessages: []string{"Bad things"},
+			Errors:        map[string]string{"summary": "You must specify a summary"},
+		}
+		err := responseError(jiraResponse(400, ""), jerr)
+		Expect(err).To(MatchError("jira returned 400 Bad Request: Bad things; " +
+			"summary: You must specify a summary"))
+		Expect(errors.Is(err, jerr)).To(BeTrue())
+	})
+	It("should return the go-jira error as is when it read a body without messages", func() {
+		jerr := &gojira.Error{HTTPError: httpErr}
+		Expect(responseError(jiraResponse(400, ""), jerr)).To(Equal(jerr))
+	})
+	It("should be returned by GetIssue when the issue does not exist", func() {
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatchHandler(
+				jmock.GetIssueByKey,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"errorMessages":["Issue Does Not Exist"],"errors":{}}`))
+				}),
+			),
+		)
+		_, err := GetIssue("OSDK-404", WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+		Expect(err).To(MatchError("jira returned 404 Not Found: Issue Does Not Exist"))
+	})
+	It("should be returned by Clone when Jira rejects the issue", func() {
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+			jmock.WithRequestMatchHandler(
+				jmock.PostIssue,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+						"errorMessages": []string{},
+						"errors":        map[string]string{"priority": "Priority name 'Urgent' is not valid"},
+					})).To(Succeed())
+				}),
+			),
+		)
+		ghissue := &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+		}
+		_, err := Clone(ghissue, WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+		Expect(err).To(MatchError("jira returned 400 Bad Request: priority: Priority name 'Urgent' is not valid"))
+	})
+})
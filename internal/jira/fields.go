@@ -0,0 +1,336 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+)
+
+// FieldInfo describes a field Jira accepts when creating an issue, as
+// reported by the create metadata of the project and issue type.
+type FieldInfo struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Required bool   `json:"required" yaml:"required"`
+	// HasDefault is true if Jira fills in the field when it is not given.
+	HasDefault bool `json:"hasDefault" yaml:"hasDefault"`
+	// Type is the schema type, e.g. string, number, option or array.
+	Type string `json:"type" yaml:"type"`
+	// Items is the type of the values of an array field.
+	Items string `json:"items,omitempty" yaml:"items,omitempty"`
+	// Custom is the type of a custom field, e.g.
+	// com.atlassian.jira.plugin.system.customfieldtypes:float.
+	Custom        string   `json:"custom,omitempty" yaml:"custom,omitempty"`
+	AllowedValues []string `json:"allowedValues,omitempty" yaml:"allowedValues,omitempty"`
+}
+
+// TypeName describes the type of the field, e.g. "number" or "array of
+// version".
+func (f FieldInfo) TypeName() string {
+	if f.Type == "array" && f.Items != "" {
+		return "array of " + f.Items
+	}
+	return f.Type
+}
+
+// CreateFields returns the fields of the create screen for issues of the
+// configured issue type in the configured project, sorted by name.
+func CreateFields(opts ...Option) ([]FieldInfo, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+	if config.project == "" {
+		return nil, fmt.Errorf("a Jira project is required")
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+	return createFields(jiraClient, config.project, config.issueType)
+}
+
+// FieldCache keeps the create fields of each project and issue type, so that
+// cloning many issues asks Jira for them once. It is safe to use from several
+// goroutines.
+type FieldCache struct {
+	lock   sync.Mutex
+	fields map[string][]FieldInfo
+}
+
+// NewFieldCache returns an empty FieldCache.
+func NewFieldCache() *FieldCache {
+	return &FieldCache{}
+}
+
+// get returns the create fields of the issue type in project, asking Jira the
+// first time. A nil FieldCache always asks Jira.
+func (fc *FieldCache) get(jiraClient *gojira.Client, project string, issueType string) ([]FieldInfo, error) {
+	if fc == nil {
+		return createFields(jiraClient, project, issueType)
+	}
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	key := strings.ToUpper(project) + "/" + strings.ToLower(issueType)
+	if fields, ok := fc.fields[key]; ok {
+		return fields, nil
+	}
+	fields, err := createFields(jiraClient, project, issueType)
+	if err != nil {
+		return nil, err
+	}
+	if fc.fields == nil {
+		fc.fields = map[string][]FieldInfo{}
+	}
+	fc.fields[key] = fields
+	return fields, nil
+}
+
+func createFields(jiraClient *gojira.Client, project string, issueType string) ([]FieldInfo, error) {
+	meta, resp, err := jiraClient.Issue.GetCreateMetaWithOptions(&gojira.GetQueryOptions{
+		ProjectKeys: project,
+		Expand:      "projects.issuetypes.fields",
+	})
+	if err != nil {
+		return nil, responseError(resp, err)
+	}
+
+	var mp *gojira.MetaProject
+	for _, p := range meta.Projects {
+		if strings.EqualFold(p.Key, project) {
+			mp = p
+		}
+	}
+	if mp == nil {
+		return nil, fmt.Errorf("project %s not found, or you can not create issues in it", project)
+	}
+
+	var types []string
+	for _, it := range mp.IssueTypes {
+		if !strings.EqualFold(it.Name, issueType) {
+			types = append(types, it.Name)
+			continue
+		}
+		fields := make([]FieldInfo, 0, len(it.Fields))
+		for id, raw := range it.Fields {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fields = append(fields, newFieldInfo(id, m))
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return strings.ToLower(fields[i].Name) < strings.ToLower(fields[j].Name)
+		})
+		return fields, nil
+	}
+	return nil, fmt.Errorf("project %s has no issue type %q, available: %s", project, issueType,
+		strings.Join(types, ", "))
+}
+
+// newFieldInfo reads the create metadata of one field.
+func newFieldInfo(id string, m map[string]interface{}) FieldInfo {
+	f := FieldInfo{ID: id}
+	f.Name, _ = m["name"].(string)
+	f.Required, _ = m["required"].(bool)
+	f.HasDefault, _ = m["hasDefaultValue"].(bool)
+	if schema, ok := m["schema"].(map[string]interface{}); ok {
+		f.Type, _ = schema["type"].(string)
+		f.Items, _ = schema["items"].(string)
+		f.Custom, _ = schema["custom"].(string)
+	}
+	if values, ok := m["allowedValues"].([]interface{}); ok {
+		for _, v := range values {
+			vm, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, k := range []string{"value", "name", "key"} {
+				if s, ok := vm[k].(string); ok && s != "" {
+					f.AllowedValues = append(f.AllowedValues, s)
+					break
+				}
+			}
+		}
+	}
+	return f
+}
+
+// FieldSetting is the value of a configured field for one cloned issue.
+type FieldSetting struct {
+	Field FieldInfo
+	// Text is the rendered value from the config.
+	Text string
+	// Value is what is sent to Jira.
+	Value interface{}
+}
+
+func (s FieldSetting) String() string {
+	return fmt.Sprintf("%s (%s) = %s", s.Field.Name, s.Field.ID, s.Text)
+}
+
+// parseFieldTemplates parses the configured field values, each is a Go
+// template executed on the Github issue with the tmpl helper functions.
+func parseFieldTemplates(fields map[string]string) (map[string]*template.Template, error) {
+	tmpls := make(map[string]*template.Template, len(fields))
+	for name, text := range fields {
+		t, err := template.New(name).Funcs(tmpl.Funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %w", name, err)
+		}
+		tmpls[name] = t
+	}
+	return tmpls, nil
+}
+
+// findField returns the field with the given ID or, ignoring case, name.
+func findField(fields []FieldInfo, key string) (FieldInfo, bool) {
+	for _, f := range fields {
+		if f.ID == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// fieldSettings renders the configured fields for the Github issue and turns
+// them into the values Jira expects, checking them against the create
+// metadata. A field that renders empty is left out.
+func fieldSettings(fields []FieldInfo, tmpls map[string]*template.Template,
+	issue *github.Issue) ([]FieldSetting, error) {
+
+	keys := make([]string, 0, len(tmpls))
+	for k := range tmpls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var settings []FieldSetting
+	for _, key := range keys {
+		f, ok := findField(fields, key)
+		if !ok {
+			return nil, fmt.Errorf("field %q is not on the create screen", key)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpls[key].Execute(&buf, issue); err != nil {
+			return nil, fmt.Errorf("unable to render field %q: %w", key, err)
+		}
+		text := strings.TrimSpace(buf.String())
+		if text == "" {
+			continue
+		}
+
+		value, err := fieldValue(f, text)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, FieldSetting{Field: f, Text: text, Value: value})
+	}
+	return settings, nil
+}
+
+// fieldValue converts the text to the JSON value Jira expects for the field.
+// Array fields take a comma separated list.
+func fieldValue(f FieldInfo, text string) (interface{}, error) {
+	if f.Type != "array" {
+		return scalarValue(f, f.Type, text)
+	}
+
+	var values []interface{}
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := scalarValue(f, f.Items, part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func scalarValue(f FieldInfo, typ string, text string) (interface{}, error) {
+	if len(f.AllowedValues) > 0 {
+		allowed := ""
+		for _, a := range f.AllowedValues {
+			if strings.EqualFold(a, text) {
+				allowed = a
+				break
+			}
+		}
+		if allowed == "" {
+			return nil, fmt.Errorf("%q is not an allowed value of field %q, must be one of: %s",
+				text, f.Name, strings.Join(f.AllowedValues, ", "))
+		}
+		text = allowed
+	}
+
+	switch typ {
+	case "number":
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %q takes a number, not %q", f.Name, text)
+		}
+		return n, nil
+	case "string", "date", "datetime", "any", "":
+		return text, nil
+	case "option", "option-with-child":
+		return map[string]interface{}{"value": text}, nil
+	}
+	// version, component, user, priority, group and the like are set by name
+	return map[string]interface{}{"name": text}, nil
+}
+
+// missingRequired returns the names of the required custom fields without a
+// default that are not set on ji, Jira would refuse to create the issue.
+func missingRequired(fields []FieldInfo, ji *gojira.Issue) []string {
+	var missing []string
+	for _, f := range fields {
+		if !f.Required || f.HasDefault || !strings.HasPrefix(f.ID, "customfield_") {
+			continue
+		}
+		if _, ok := ji.Fields.Unknowns[f.ID]; !ok {
+			missing = append(missing, fmt.Sprintf("%s (%s)", f.Name, f.ID))
+		}
+	}
+	return missing
+}
@@ -0,0 +1,266 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"text/template"
+
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+// createMeta builds the body the Jira createmeta endpoint returns for the
+// OSDK project with a Story issue type.
+func createMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"projects": []interface{}{
+			map[string]interface{}{
+				"key": "OSDK",
+				"issuetypes": []interface{}{
+					map[string]interface{}{"name": "Bug", "fields": map[string]interface{}{}},
+					map[string]interface{}{
+						"name": "Story",
+						"fields": map[string]interface{}{
+							"summary": map[string]interface{}{
+								"name": "Summary", "required": true,
+								"schema": map[string]interface{}{"type": "string"},
+							},
+							"customfield_10002": map[string]interface{}{
+								"name": "Story Points",
+								"schema": map[string]interface{}{"type": "number",
+									"custom": "com.atlassian.jira.plugin.system.customfieldtypes:float"},
+							},
+							"customfield_10003": map[string]interface{}{
+								"name": "Team", "required": true,
+								"schema": map[string]interface{}{"type": "option",
+									"custom": "com.atlassian.jira.plugin.system.customfieldtypes:select"},
+								"allowedValues": []interface{}{
+									map[string]interface{}{"id": "1", "value": "Operator SDK"},
+									map[string]interface{}{"id": "2", "value": "OLM"},
+								},
+							},
+							"customfield_10004": map[string]interface{}{
+								"name":   "Target Version",
+								"schema": map[string]interface{}{"type": "array", "items": "version"},
+								"allowedValues": []interface{}{
+									map[string]interface{}{"id": "3", "name": "v1.25.0"},
+									map[string]interface{}{"id": "4", "name": "v1.26.0"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("Fields", func() {
+	Context("CreateFields", func() {
+		It("should list the fields of the issue type sorted by name", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetCreateMeta, createMeta()),
+			)
+			fields, err := CreateFields(WithClient(mockedHTTPClient), WithJiraURL("http://localhost"),
+				WithProject("OSDK"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fields).To(HaveLen(4))
+			Expect(fields[0].Name).To(Equal("Story Points"))
+			Expect(fields[0].TypeName()).To(Equal("number"))
+			Expect(fields[1].Name).To(Equal("Summary"))
+			Expect(fields[3]).To(Equal(FieldInfo{
+				ID:            "customfield_10003",
+				Name:          "Team",
+				Required:      true,
+				Type:          "option",
+				Custom:        "com.atlassian.jira.plugin.system.customfieldtypes:select",
+				AllowedValues: []string{"Operator SDK", "OLM"},
+			}))
+			Expect(fields[2].TypeName()).To(Equal("array of version"))
+			Expect(fields[2].AllowedValues).To(Equal([]string{"v1.25.0", "v1.26.0"}))
+		})
+		It("should name the available issue types", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetCreateMeta, createMeta()),
+			)
+			_, err := CreateFields(WithClient(mockedHTTPClient), WithJiraURL("http://localhost"),
+				WithProject("OSDK"), WithIssueType("Epic"))
+			Expect(err).To(MatchError(`project OSDK has no issue type "Epic", available: Bug, Story`))
+		})
+		It("should fail for an unknown project", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetCreateMeta, createMeta()),
+			)
+			_, err := CreateFields(WithClient(mockedHTTPClient), WithJiraURL("http://localhost"),
+				WithProject("NOPE"))
+			Expect(err).To(MatchError(ContainSubstring("project NOPE not found")))
+		})
+	})
+	Context("fieldValue", func() {
+		team := FieldInfo{Name: "Team", Type: "option", AllowedValues: []string{"Operator SDK", "OLM"}}
+		versions := FieldInfo{Name: "Target Version", Type: "array", Items: "version"}
+
+		It("should convert numbers", func() {
+			v, err := fieldValue(FieldInfo{Name: "Story Points", Type: "number"}, "3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(3.0))
+
+			_, err = fieldValue(FieldInfo{Name: "Story Points", Type: "number"}, "three")
+			Expect(err).To(MatchError(`field "Story Points" takes a number, not "three"`))
+		})
+		It("should use the allowed value as Jira spells it", func() {
+			v, err := fieldValue(team, "olm")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(map[string]interface{}{"value": "OLM"}))
+		})
+		It("should reject values that are not allowed", func() {
+			_, err := fieldValue(team, "Kubernetes")
+			Expect(err).To(MatchError(`"Kubernetes" is not an allowed value of field "Team", ` +
+				"must be one of: Operator SDK, OLM"))
+		})
+		It("should split array values on commas", func() {
+			v, err := fieldValue(versions, "v1.25.0, v1.26.0,")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal([]interface{}{
+				map[string]interface{}{"name": "v1.25.0"},
+				map[string]interface{}{"name": "v1.26.0"},
+			}))
+		})
+	})
+	Context("fieldSettings", func() {
+		fields := []FieldInfo{
+			{ID: "customfield_10002", Name: "Story Points", Type: "number"},
+			{ID: "customfield_10004", Name: "Target Version", Type: "array", Items: "version"},
+		}
+		It("should render the templates with the Github issue", func() {
+			tmpls, err := parseFieldTemplates(map[string]string{
+				"story points":      "3",
+				"customfield_10004": "{{ .GetMilestone.GetTitle }}",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			settings, err := fieldSettings(fields, tmpls, &github.Issue{
+				Milestone: &github.Milestone{Title: github.String("v1.25.0")},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings).To(HaveLen(2))
+			Expect(settings[0].String()).To(Equal("Target Version (customfield_10004) = v1.25.0"))
+			Expect(settings[1].String()).To(Equal("Story Points (customfield_10002) = 3"))
+		})
+		It("should leave out fields that render empty", func() {
+			tmpls := map[string]*template.Template{
+				"Target Version": template.Must(template.New("").Parse("{{ .GetMilestone.GetTitle }}")),
+			}
+			settings, err := fieldSettings(fields, tmpls, &github.Issue{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings).To(BeEmpty())
+		})
+		It("should fail for a field that is not on the create screen", func() {
+			tmpls := map[string]*template.Template{
+				"Team": template.Must(template.New("").Parse("OLM")),
+			}
+			_, err := fieldSettings(fields, tmpls, &github.Issue{})
+			Expect(err).To(MatchError(`field "Team" is not on the create screen`))
+		})
+	})
+	Context("Clone", func() {
+		ghissue := &github.Issue{
+			Number:    github.Int(123),
+			Title:     github.String("Issue 1"),
+			URL:       github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			Milestone: &github.Milestone{Title: github.String("v1.26.0")},
+		}
+
+		It("should set the configured fields on the new issue", func() {
+			var created map[string]map[string]interface{}
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.GetCreateMeta, createMeta()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write([]byte(`{"key": "OSDK-9"}`))
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithProject("OSDK"),
+				WithFields(map[string]string{
+					"Story Points":   "3",
+					"Team":           "operator sdk",
+					"Target Version": "{{ .GetMilestone.GetTitle }}",
+				}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created["fields"]).To(HaveKeyWithValue("customfield_10002", 3.0))
+			Expect(created["fields"]).To(HaveKeyWithValue("customfield_10003",
+				map[string]interface{}{"value": "Operator SDK"}))
+			Expect(created["fields"]).To(HaveKeyWithValue("customfield_10004",
+				[]interface{}{map[string]interface{}{"name": "v1.26.0"}}))
+		})
+		It("should ask Jira for the create fields once per project and issue type", func() {
+			metaRequests := 0
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult(), searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.GetCreateMeta,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						metaRequests++
+						w.Write(jmock.MustMarshal(createMeta()))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Write([]byte(`{"key": "OSDK-9"}`))
+					}),
+				),
+			)
+
+			cache := NewFieldCache()
+			for i := 0; i < 2; i++ {
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+					WithProject("OSDK"),
+					WithFields(map[string]string{"Story Points": "3", "Team": "operator sdk"}),
+					WithFieldCache(cache),
+				)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(metaRequests).To(Equal(1))
+		})
+		It("should not create the issue when a required field is missing", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.GetCreateMeta, createMeta()),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithProject("OSDK"),
+				WithFields(map[string]string{"Story Points": "3"}),
+			)
+			Expect(err).To(MatchError("required fields are not set: Team (customfield_10003)"))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+)
+
+// GetIssue returns the Jira issue with the given key.
+func GetIssue(key string, opts ...Option) (*gojira.Issue, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ji, resp, err := jiraClient.Issue.Get(key, nil)
+	if err != nil {
+		return nil, responseError(resp, err)
+	}
+	return ji, nil
+}
+
+// LinkGithub links the Jira issue key to the Github issue the way Clone does.
+func LinkGithub(key string, issue *github.Issue, opts ...Option) error {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return err
+	}
+
+	weburl := issue.GetHTMLURL()
+	ji, resp, err := jiraClient.Issue.Get(key, &gojira.GetQueryOptions{Fields: "description"})
+	if err != nil {
+		return responseError(resp, err)
+	}
+	description := ""
+	if ji.Fields != nil {
+		description = ji.Fields.Description
+	}
+	if upstream, ok := UpstreamURL(description); ok {
+		return fmt.Errorf("%s is already linked to %s", key, upstream)
+	}
+
+	update := &gojira.Issue{
+		Key: key,
+		Fields: &gojira.IssueFields{
+			Description: upstreamDescription(description, weburl),
+		},
+	}
+	if _, resp, err := jiraClient.Issue.Update(update); err != nil {
+		return responseError(resp, err)
+	}
+
+	comment := &gojira.Comment{Body: fmt.Sprintf("Cloned to Github issue: %s", weburl)}
+	if _, resp, err := jiraClient.Issue.AddComment(key, comment); err != nil {
+		return responseError(resp, err)
+	}
+	if link := config.remoteLink(issue); link != nil {
+		addRemoteLink(jiraClient, key, link)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Issues", func() {
+	var (
+		originalToken string
+	)
+	BeforeEach(func() {
+		originalToken = os.Getenv("JIRA_TOKEN")
+		err := os.Setenv("JIRA_TOKEN", "blah-blah-blah")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		err := os.Setenv("JIRA_TOKEN", originalToken)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("GetIssue", func() {
+		It("should return the issue", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key:    "OSDK-1",
+					Fields: &gojira.IssueFields{Summary: "Jira summary"},
+				}),
+			)
+			ji, err := GetIssue("OSDK-1", WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ji.Fields.Summary).To(Equal("Jira summary"))
+		})
+		It("should return Jira's response for a missing issue", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					jmock.GetIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"errorMessages":["Issue Does Not Exist"]}`))
+					}),
+				),
+			)
+			_, err := GetIssue("OSDK-1", WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).To(MatchError(ContainSubstring("Issue Does Not Exist")))
+		})
+	})
+
+	Describe("LinkGithub", func() {
+		weburl := "https://github.com/operator-framework/operator-sdk/issues/42"
+		ghissue := &github.Issue{
+			Number:  github.Int(42),
+			Title:   github.String("from jira"),
+			State:   github.String("open"),
+			HTMLURL: github.String(weburl),
+		}
+
+		It("should add the upstream link, a comment and a remote link", func() {
+			var update gojira.Issue
+			var comment gojira.Comment
+			var link gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key:    "OSDK-1",
+					Fields: &gojira.IssueFields{Description: "from jira"},
+				}),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&update)).To(Succeed())
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						w.Write(jmock.MustMarshal(link))
+					}),
+				),
+			)
+			err := LinkGithub("OSDK-1", ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.Fields.Description).To(Equal(upstreamDescription("from jira", weburl)))
+			Expect(comment.Body).To(ContainSubstring(weburl))
+			Expect(link.GlobalID).To(Equal(weburl))
+			Expect(link.Object.Title).To(Equal("operator-framework/operator-sdk#42"))
+		})
+		It("should return an error if the issue is already linked", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key: "OSDK-1",
+					Fields: &gojira.IssueFields{
+						Description: upstreamDescription("from jira", weburl),
+					},
+				}),
+			)
+			err := LinkGithub("OSDK-1", ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).To(MatchError("OSDK-1 is already linked to " + weburl))
+		})
+	})
+})
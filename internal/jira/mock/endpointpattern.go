@@ -18,3 +18,63 @@ var PostIssue EndpointPattern = EndpointPattern{
 	Pattern: "/rest/api/2/issue",
 	Method:  "POST",
 }
+
+var GetIssueByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}",
+	Method:  "GET",
+}
+
+var PutIssueByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}",
+	Method:  "PUT",
+}
+
+var GetSearch EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/search",
+	Method:  "GET",
+}
+
+var GetIssueTransitionsByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/transitions",
+	Method:  "GET",
+}
+
+var PostIssueTransitionsByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/transitions",
+	Method:  "POST",
+}
+
+var PostIssueCommentByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/comment",
+	Method:  "POST",
+}
+
+var GetFields EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/field",
+	Method:  "GET",
+}
+
+var GetCreateMeta EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/createmeta",
+	Method:  "GET",
+}
+
+var GetUserSearch EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/user/search",
+	Method:  "GET",
+}
+
+var PostIssueRemoteLinkByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/remotelink",
+	Method:  "POST",
+}
+
+var PostIssueAttachmentsByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/attachments",
+	Method:  "POST",
+}
+
+var GetIssueRemoteLinkByKey EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{key}/remotelink",
+	Method:  "GET",
+}
@@ -0,0 +1,185 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// PlanVersion is the version of the plan file format.
+const PlanVersion = 1
+
+// Plan holds the Jira requests of a dry run for Apply.
+type Plan struct {
+	lock    sync.Mutex
+	Version int            `json:"version"`
+	JiraURL string         `json:"jiraURL"`
+	Issues  []PlannedIssue `json:"issues"`
+}
+
+// PlannedIssue is a Jira issue a dry run would create, or update when Key is
+// set.
+type PlannedIssue struct {
+	// Project, Number and URL identify the Github issue.
+	Project string `json:"project"`
+	Number  int    `json:"number"`
+	URL     string `json:"url"`
+	// Key is the existing Jira issue to update, empty to create one.
+	Key string `json:"key,omitempty"`
+	// Payload is the exact body sent to Jira's /rest/api/2/issue.
+	Payload *gojira.Issue `json:"payload"`
+	// Comments are added to the new Jira issue, in order.
+	Comments []string `json:"comments,omitempty"`
+	// RemoteLink is added to, or updated on, the Jira issue.
+	RemoteLink *gojira.RemoteLink `json:"remoteLink,omitempty"`
+	// Attachments are the Github files mirrored to the new Jira issue.
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// Add records a planned issue.
+func (p *Plan) Add(pi PlannedIssue) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.Issues = append(p.Issues, pi)
+}
+
+// Write writes the plan to w as indented JSON.
+func (p *Plan) Write(w io.Writer) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.Version == 0 {
+		p.Version = PlanVersion
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// ReadPlan reads a plan written by Write, checking every issue in it.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+	if p.Version != PlanVersion {
+		return nil, fmt.Errorf("invalid plan: unsupported version %d, expected %d", p.Version, PlanVersion)
+	}
+	if p.JiraURL == "" {
+		return nil, fmt.Errorf("invalid plan: missing jiraURL")
+	}
+	for i, pi := range p.Issues {
+		if pi.URL == "" || pi.Payload == nil || pi.Payload.Fields == nil {
+			return nil, fmt.Errorf("invalid plan: issue %d is missing its url or payload", i+1)
+		}
+		if pi.Key == "" && pi.Payload.Fields.Project.Key == "" {
+			return nil, fmt.Errorf("invalid plan: %s has no Jira project", pi.URL)
+		}
+	}
+	return &p, nil
+}
+
+// Apply makes the Jira requests of a planned issue, skipping it if cloned since.
+func Apply(pi PlannedIssue, opts ...Option) (*gojira.Issue, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		daIssue *gojira.Issue
+		outcome = Failed
+	)
+	err := config.setDefaults()
+	if err == nil {
+		daIssue, outcome, err = apply(&config, pi)
+	}
+	config.recordLink(pi.Project, pi.Number, daIssue, outcome)
+	config.annotate(pi.Project, pi.Number, daIssue, outcome)
+	if config.report != nil {
+		entry := ReportEntry{
+			Project: pi.Project,
+			Number:  pi.Number,
+			URL:     pi.URL,
+			Outcome: outcome,
+			Err:     err,
+		}
+		if daIssue != nil {
+			entry.Key = daIssue.Key
+		}
+		config.report.Add(entry)
+	}
+	return daIssue, err
+}
+
+// apply does the work of Apply and returns what it did with the issue.
+func apply(config *ClonerConfig, pi PlannedIssue) (*gojira.Issue, Outcome, error) {
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, Failed, err
+	}
+
+	ji := *pi.Payload
+	if pi.Key != "" {
+		ji.Key = pi.Key
+		updated, resp, err := jiraClient.Issue.Update(&ji)
+		if err != nil {
+			return nil, Failed, responseError(resp, err)
+		}
+		fmt.Printf("Issue %s#%d: updated %s\n", pi.Project, pi.Number, pi.Key)
+		if updated == nil || updated.Key == "" {
+			updated = &gojira.Issue{Key: pi.Key}
+		}
+		if pi.RemoteLink != nil {
+			addRemoteLink(jiraClient, pi.Key, pi.RemoteLink)
+		}
+		return updated, Updated, nil
+	}
+
+	existing, err := config.findExisting(jiraClient, ji.Fields.Project.Key, pi.URL, pi.Number)
+	if err != nil {
+		return nil, Failed, err
+	}
+	if existing != nil {
+		fmt.Printf("Issue %s#%d already cloned to %s; skipping\n", pi.Project, pi.Number,
+			BrowseURL(config.jiraURL, existing.Key))
+		return existing, Skipped, nil
+	}
+
+	daIssue, resp, err := jiraClient.Issue.Create(&ji)
+	if err != nil {
+		return nil, Failed, responseError(resp, err)
+	}
+	fmt.Printf("Issue %s#%d cloned; see %s\n", pi.Project, pi.Number, BrowseURL(config.jiraURL, daIssue.Key))
+
+	names := attachToDescription(jiraClient, config, daIssue.Key, ji.Fields.Description, pi.Attachments)
+
+	for _, body := range pi.Comments {
+		body = rewriteAttachments(body, names)
+		if _, resp, err := jiraClient.Issue.AddComment(daIssue.Key, &gojira.Comment{Body: body}); err != nil {
+			fmt.Printf("Warning: unable to add a comment to %s: %v\n", daIssue.Key, responseError(resp, err))
+		}
+	}
+	if pi.RemoteLink != nil {
+		addRemoteLink(jiraClient, daIssue.Key, pi.RemoteLink)
+	}
+	return daIssue, Created, nil
+}
@@ -0,0 +1,347 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+var _ = Describe("Plan", func() {
+	ghissue := &github.Issue{
+		Number: github.Int(123),
+		Title:  github.String("Issue 1"),
+		Body:   github.String("body of the issue"),
+		URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+	}
+
+	It("should record the payload of a dry run", func() {
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+		)
+		comments := []*github.IssueComment{
+			{Body: github.String("first"), User: &github.User{Login: github.String("a")}},
+		}
+
+		plan := &Plan{JiraURL: "http://localhost"}
+		_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+			WithJiraURL("http://localhost"),
+			WithProject("OSDK"),
+			WithDryRun(true),
+			WithComments(comments),
+			WithPlan(plan),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Issues).To(HaveLen(1))
+		Expect(plan.Issues[0].Project).To(Equal("foo/bar"))
+		Expect(plan.Issues[0].Number).To(Equal(123))
+		Expect(plan.Issues[0].URL).To(Equal("https://github.com/foo/bar/issues/123"))
+		Expect(plan.Issues[0].Key).To(BeEmpty())
+		Expect(plan.Issues[0].Payload.Fields.Summary).To(Equal("[UPSTREAM] Issue 1 #123"))
+		Expect(plan.Issues[0].Payload.Fields.Project.Key).To(Equal("OSDK"))
+		Expect(plan.Issues[0].Comments).To(HaveLen(1))
+		Expect(plan.Issues[0].Comments[0]).To(HaveSuffix("first"))
+		Expect(plan.Issues[0].RemoteLink.GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+	})
+	It("should plan an update of an issue that was already cloned", func() {
+		existing := gojira.Issue{
+			Key: "OSDK-42",
+			Fields: &gojira.IssueFields{
+				Description: "old body\n\nUpstream Github issue: https://github.com/foo/bar/issues/123\n",
+			},
+		}
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+		)
+
+		plan := &Plan{}
+		_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+			WithJiraURL("http://localhost"),
+			WithProject("OSDK"),
+			WithDryRun(true),
+			WithOnExisting(ExistingUpdate),
+			WithPlan(plan),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Issues).To(HaveLen(1))
+		Expect(plan.Issues[0].Key).To(Equal("OSDK-42"))
+		Expect(plan.Issues[0].Payload.Fields.Description).To(HavePrefix("body of the issue"))
+	})
+	It("should read back what it writes", func() {
+		plan := &Plan{JiraURL: "http://localhost"}
+		plan.Add(PlannedIssue{
+			Project: "foo/bar",
+			Number:  123,
+			URL:     "https://github.com/foo/bar/issues/123",
+			Payload: &gojira.Issue{Fields: &gojira.IssueFields{
+				Summary:  "[UPSTREAM] Issue 1 #123",
+				Project:  gojira.Project{Key: "OSDK"},
+				Unknowns: map[string]interface{}{"customfield_10002": 3.0},
+			}},
+			Comments: []string{"first"},
+		})
+
+		var buf bytes.Buffer
+		Expect(plan.Write(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`"customfield_10002": 3`))
+
+		read, err := ReadPlan(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Version).To(Equal(PlanVersion))
+		Expect(read.JiraURL).To(Equal("http://localhost"))
+		Expect(read.Issues).To(HaveLen(1))
+		Expect(read.Issues[0].Payload.Fields.Summary).To(Equal("[UPSTREAM] Issue 1 #123"))
+		Expect(read.Issues[0].Payload.Fields.Unknowns).To(HaveKeyWithValue("customfield_10002", 3.0))
+		Expect(read.Issues[0].Comments).To(Equal([]string{"first"}))
+	})
+	It("should reject an invalid plan", func() {
+		_, err := ReadPlan(strings.NewReader(`{"version": 2, "jiraURL": "http://localhost"}`))
+		Expect(err).To(MatchError("invalid plan: unsupported version 2, expected 1"))
+
+		_, err = ReadPlan(strings.NewReader(`{"version": 1, "jiraURL": "http://localhost", ` +
+			`"issues": [{"url": "https://github.com/foo/bar/issues/1"}]}`))
+		Expect(err).To(MatchError("invalid plan: issue 1 is missing its url or payload"))
+
+		_, err = ReadPlan(strings.NewReader(`not json`))
+		Expect(err).To(HaveOccurred())
+	})
+	Context("Apply", func() {
+		planned := PlannedIssue{
+			Project: "foo/bar",
+			Number:  123,
+			URL:     "https://github.com/foo/bar/issues/123",
+			Payload: &gojira.Issue{Fields: &gojira.IssueFields{
+				Summary:     "[UPSTREAM] Issue 1 #123",
+				Description: "body\n\nUpstream Github issue: https://github.com/foo/bar/issues/123\n",
+				Project:     gojira.Project{Key: "OSDK"},
+				Unknowns:    map[string]interface{}{"customfield_10002": 3.0},
+			}},
+			Comments: []string{"first", "second"},
+		}
+
+		It("should send the planned payload and comments", func() {
+			var (
+				body     map[string]interface{}
+				comments []string
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+						w.Write([]byte(`{"key": "OSDK-7"}`))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment gojira.Comment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						comments = append(comments, comment.Body)
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+			)
+
+			report := &Report{}
+			jissue, err := Apply(planned, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithReport(report),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-7"))
+
+			expected, err := json.Marshal(planned.Payload)
+			Expect(err).NotTo(HaveOccurred())
+			sent, err := json.Marshal(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(MatchJSON(expected))
+			Expect(comments).To(Equal([]string{"first", "second"}))
+
+			Expect(report.Entries).To(HaveLen(1))
+			Expect(report.Entries[0].Project).To(Equal("foo/bar"))
+			Expect(report.Entries[0].Key).To(Equal("OSDK-7"))
+			Expect(report.Entries[0].Outcome).To(Equal(Created))
+		})
+		It("should add the planned remote link", func() {
+			withLink := planned
+			withLink.RemoteLink = &gojira.RemoteLink{GlobalID: "https://github.com/foo/bar/issues/123"}
+			var link gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatch(jmock.PostIssueCommentByKey, gojira.Comment{}, gojira.Comment{}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						w.Write(jmock.MustMarshal(link))
+					}),
+				),
+			)
+			_, err := Apply(withLink, WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link.GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+		})
+		It("should carry on when a planned comment can not be added", func() {
+			withLink := planned
+			withLink.RemoteLink = &gojira.RemoteLink{GlobalID: "https://github.com/foo/bar/issues/123"}
+			var (
+				comments []string
+				link     gojira.RemoteLink
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var comment gojira.Comment
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						if comment.Body == "first" {
+							jmock.WriteError(w, http.StatusBadRequest, "comment too long")
+							return
+						}
+						comments = append(comments, comment.Body)
+						w.Write(jmock.MustMarshal(comment))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						w.Write(jmock.MustMarshal(link))
+					}),
+				),
+			)
+			report := &Report{}
+			jissue, err := Apply(withLink, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithReport(report),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-7"))
+			Expect(comments).To(Equal([]string{"second"}))
+			Expect(link.GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+			Expect(report.Entries[0].Outcome).To(Equal(Created))
+		})
+		It("should annotate the Github issue of the created issue", func() {
+			annotator := &fakeAnnotator{}
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatch(jmock.PostIssueCommentByKey, gojira.Comment{}, gojira.Comment{}),
+			)
+			_, err := Apply(planned, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithAnnotator(annotator),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotator.annotated).To(Equal([]string{"foo/bar#123 OSDK-7"}))
+		})
+		It("should mirror the planned attachments", func() {
+			withFile := planned
+			withFile.Comments = nil
+			withFile.Attachments = []string{"https://github.com/user-attachments/files/55/build.log"}
+			var uploaded string
+			downloads := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(getUserAttachmentFile, "build output"),
+			)
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueAttachmentsByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, header, err := r.FormFile("file")
+						Expect(err).NotTo(HaveOccurred())
+						uploaded = header.Filename
+						w.Write(jmock.MustMarshal([]gojira.Attachment{{Filename: header.Filename}}))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+			_, err := Apply(withFile, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithDownloadClient(downloads),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploaded).To(Equal("build.log"))
+		})
+		It("should skip an issue cloned since the plan was made", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult(gojira.Issue{
+					Key:    "OSDK-42",
+					Fields: &gojira.IssueFields{Description: planned.Payload.Fields.Description},
+				})),
+			)
+
+			report := &Report{}
+			jissue, err := Apply(planned, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithReport(report),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-42"))
+			Expect(report.Entries[0].Outcome).To(Equal(Skipped))
+		})
+		It("should update the planned issue", func() {
+			var body []byte
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.URL.Path).To(HaveSuffix("/OSDK-42"))
+						body, _ = io.ReadAll(r.Body)
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+
+			update := PlannedIssue{
+				Project: "foo/bar",
+				Number:  123,
+				URL:     "https://github.com/foo/bar/issues/123",
+				Key:     "OSDK-42",
+				Payload: &gojira.Issue{Fields: &gojira.IssueFields{Summary: "new summary"}},
+			}
+			report := &Report{}
+			jissue, err := Apply(update, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithReport(report),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-42"))
+			Expect(string(body)).To(ContainSubstring("new summary"))
+			Expect(report.Entries[0].Outcome).To(Equal(Updated))
+		})
+	})
+})
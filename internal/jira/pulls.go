@@ -0,0 +1,168 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// PullRequestLabel is added to every Jira issue cloned from a pull request,
+// so they can be told apart from cloned issues.
+const PullRequestLabel = "upstream-pr"
+
+// closingRef matches the keywords Github uses to link a pull request to the
+// issues it closes, e.g. "Fixes #12" or "closes org/repo#3".
+var closingRef = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+` +
+	`((?:[\w.-]+/[\w.-]+)?#\d+|https://github\.com/[\w.-]+/[\w.-]+/issues/\d+)`)
+
+// linkedIssues returns the issues the pull request body says it closes, as
+// #N for the same repository or org/repo#N for another one.
+func linkedIssues(body string, repo string) []string {
+	var refs []string
+	seen := map[string]bool{}
+	for _, m := range closingRef.FindAllStringSubmatch(body, -1) {
+		ref := m[1]
+		if strings.HasPrefix(ref, "https://") {
+			parts := strings.Split(strings.TrimPrefix(ref, "https://github.com/"), "/")
+			ref = fmt.Sprintf("%s/%s#%s", parts[0], parts[1], parts[3])
+		}
+		if repo != "" && strings.HasPrefix(ref, repo+"#") {
+			ref = strings.TrimPrefix(ref, repo)
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// reviewSummary describes where the reviews of a pull request stand, using
+// the latest review of each reviewer. A comment does not undo an approval or
+// a request for changes, a dismissal does.
+func reviewSummary(reviews []*github.PullRequestReview) string {
+	var reviewers []string
+	latest := map[string]string{}
+	for _, r := range reviews {
+		login := r.GetUser().GetLogin()
+		state := r.GetState()
+		prev, ok := latest[login]
+		if !ok {
+			reviewers = append(reviewers, login)
+		}
+		switch state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[login] = state
+		case "COMMENTED":
+			if prev == "" || prev == "DISMISSED" {
+				latest[login] = state
+			}
+		}
+	}
+
+	groups := []struct {
+		state string
+		text  string
+	}{
+		{"APPROVED", "approved by"},
+		{"CHANGES_REQUESTED", "changes requested by"},
+		{"COMMENTED", "commented on by"},
+	}
+	var parts []string
+	for _, g := range groups {
+		var logins []string
+		for _, login := range reviewers {
+			if latest[login] == g.state {
+				logins = append(logins, login)
+			}
+		}
+		if len(logins) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", g.text, strings.Join(logins, ", ")))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// pullRequestStatus describes whether the pull request is open, a draft,
+// merged or closed.
+func pullRequestStatus(pr *github.PullRequest) string {
+	switch {
+	case pr.GetMerged() || !pr.GetMergedAt().IsZero():
+		status := "merged"
+		if login := pr.GetMergedBy().GetLogin(); login != "" {
+			status += " by " + login
+		}
+		if !pr.GetMergedAt().IsZero() {
+			status += " on " + pr.GetMergedAt().UTC().Format("2006-01-02")
+		}
+		return status
+	case pr.GetState() == "closed":
+		return "closed without merging"
+	case pr.GetDraft():
+		return "open (draft)"
+	}
+	return "open"
+}
+
+// mergeability describes whether an open pull request can be merged.
+func mergeability(pr *github.PullRequest) string {
+	if pr.Mergeable == nil {
+		return "unknown"
+	}
+	m := "no"
+	if pr.GetMergeable() {
+		m = "yes"
+	}
+	if s := pr.GetMergeableState(); s != "" && s != "unknown" {
+		m = fmt.Sprintf("%s (%s)", m, s)
+	}
+	return m
+}
+
+// pullRequestBlock renders the pull request metadata as a Jira wiki table
+// for the top of the cloned description.
+func pullRequestBlock(pr *github.PullRequest, reviews []*github.PullRequestReview) string {
+	repo := pr.GetBase().GetRepo().GetFullName()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "||Pull request|[%s#%d|%s]|\n", repo, pr.GetNumber(), pr.GetHTMLURL())
+	fmt.Fprintf(&b, "||Branches|{{%s}} into {{%s}}|\n", pr.GetHead().GetLabel(), pr.GetBase().GetRef())
+	fmt.Fprintf(&b, "||Status|%s|\n", pullRequestStatus(pr))
+	if pr.GetState() == "open" {
+		fmt.Fprintf(&b, "||Mergeable|%s|\n", mergeability(pr))
+	}
+	fmt.Fprintf(&b, "||Reviews|%s|\n", reviewSummary(reviews))
+
+	if refs := linkedIssues(pr.GetBody(), repo); len(refs) > 0 {
+		links := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			target := repo + ref
+			if !strings.HasPrefix(ref, "#") {
+				target = ref
+			}
+			parts := strings.SplitN(target, "#", 2)
+			links = append(links, fmt.Sprintf("[%s|https://github.com/%s/issues/%s]", ref, parts[0], parts[1]))
+		}
+		fmt.Fprintf(&b, "||Linked issues|%s|\n", strings.Join(links, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func review(login string, state string) *github.PullRequestReview {
+	return &github.PullRequestReview{
+		User:  &github.User{Login: github.String(login)},
+		State: github.String(state),
+	}
+}
+
+var _ = Describe("Pull requests", func() {
+	Describe("linkedIssues", func() {
+		It("should find the issues closed by the pull request", func() {
+			body := "This fixes #12 and Closes: other/repo#3.\n\n" +
+				"resolves https://github.com/foo/bar/issues/7, see #99, fixes #12"
+			Expect(linkedIssues(body, "foo/bar")).To(Equal([]string{"#12", "other/repo#3", "#7"}))
+		})
+		It("should not shorten a repository that only shares a prefix", func() {
+			Expect(linkedIssues("fixes foo/bar2#1", "foo/bar")).To(Equal([]string{"foo/bar2#1"}))
+		})
+		It("should return nothing without closing keywords", func() {
+			Expect(linkedIssues("related to #12", "foo/bar")).To(BeEmpty())
+		})
+	})
+
+	Describe("reviewSummary", func() {
+		It("should say none without reviews", func() {
+			Expect(reviewSummary(nil)).To(Equal("none"))
+		})
+		It("should use the latest review of each reviewer", func() {
+			Expect(reviewSummary([]*github.PullRequestReview{
+				review("alice", "CHANGES_REQUESTED"),
+				review("bob", "COMMENTED"),
+				review("alice", "APPROVED"),
+				review("carol", "APPROVED"),
+				review("carol", "COMMENTED"),
+				review("dave", "CHANGES_REQUESTED"),
+				review("erin", "APPROVED"),
+				review("erin", "DISMISSED"),
+			})).To(Equal("approved by alice, carol; changes requested by dave; commented on by bob"))
+		})
+	})
+
+	Describe("pullRequestStatus", func() {
+		It("should describe a merged pull request", func() {
+			mergedAt := time.Date(2022, 6, 30, 15, 4, 5, 0, time.UTC)
+			pr := &github.PullRequest{
+				State:    github.String("closed"),
+				Merged:   github.Bool(true),
+				MergedBy: &github.User{Login: github.String("jmrodri")},
+				MergedAt: &mergedAt,
+			}
+			Expect(pullRequestStatus(pr)).To(Equal("merged by jmrodri on 2022-06-30"))
+		})
+		It("should describe a closed pull request", func() {
+			pr := &github.PullRequest{State: github.String("closed")}
+			Expect(pullRequestStatus(pr)).To(Equal("closed without merging"))
+		})
+		It("should describe a draft", func() {
+			pr := &github.PullRequest{State: github.String("open"), Draft: github.Bool(true)}
+			Expect(pullRequestStatus(pr)).To(Equal("open (draft)"))
+		})
+	})
+
+	Describe("mergeability", func() {
+		It("should include the mergeable state", func() {
+			pr := &github.PullRequest{
+				Mergeable:      github.Bool(false),
+				MergeableState: github.String("dirty"),
+			}
+			Expect(mergeability(pr)).To(Equal("no (dirty)"))
+		})
+		It("should say unknown before Github has computed it", func() {
+			Expect(mergeability(&github.PullRequest{})).To(Equal("unknown"))
+		})
+	})
+
+	Describe("pullRequestBlock", func() {
+		It("should leave out the mergeability of a closed pull request", func() {
+			pr := &github.PullRequest{
+				Number:  github.Int(5),
+				State:   github.String("closed"),
+				HTMLURL: github.String("https://github.com/foo/bar/pull/5"),
+				Head:    &github.PullRequestBranch{Label: github.String("foo:feature")},
+				Base: &github.PullRequestBranch{
+					Ref:  github.String("main"),
+					Repo: &github.Repository{FullName: github.String("foo/bar")},
+				},
+			}
+			Expect(pullRequestBlock(pr, []*github.PullRequestReview{review("bob", "APPROVED")})).To(Equal(
+				"||Pull request|[foo/bar#5|https://github.com/foo/bar/pull/5]|\n" +
+					"||Branches|{{foo:feature}} into {{main}}|\n" +
+					"||Status|closed without merging|\n" +
+					"||Reviews|approved by bob|\n"))
+		})
+	})
+})
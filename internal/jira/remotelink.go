@@ -0,0 +1,146 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+)
+
+// githubIcon is shown next to the remote links to Github issues.
+var githubIcon = &gojira.RemoteLinkIcon{
+	Url16x16: "https://github.com/favicon.ico",
+	Title:    "GitHub",
+}
+
+// remoteLink returns the Jira remote link to the Github issue, keyed by its URL.
+func remoteLink(issue *github.Issue) *gojira.RemoteLink {
+	weburl := getWebURL(issue.GetURL())
+	if weburl == "" {
+		weburl = issue.GetHTMLURL()
+	}
+
+	kind, status := "issue", "Open"
+	if issue.IsPullRequest() {
+		kind = "pull request"
+	}
+	if issue.GetState() == "closed" {
+		status = "Closed"
+	}
+
+	return &gojira.RemoteLink{
+		GlobalID: weburl,
+		Application: &gojira.RemoteLinkApplication{
+			Type: "com.github",
+			Name: "GitHub",
+		},
+		Relationship: "Upstream Github " + kind,
+		Object: &gojira.RemoteLinkObject{
+			URL:     weburl,
+			Title:   fmt.Sprintf("%s#%d", webURLProject(weburl), issue.GetNumber()),
+			Summary: issue.GetTitle(),
+			Icon:    githubIcon,
+			Status: &gojira.RemoteLinkStatus{
+				Resolved: status == "Closed",
+				Icon: &gojira.RemoteLinkIcon{
+					Title: status,
+					Link:  weburl,
+				},
+			},
+		},
+	}
+}
+
+// remoteLink returns the remote link to add to the clone of the Github issue,
+// nil if there is none.
+func (c *ClonerConfig) remoteLink(issue *github.Issue) *gojira.RemoteLink {
+	if c.noRemoteLink {
+		return nil
+	}
+	return remoteLink(issue)
+}
+
+// addRemoteLink adds or updates the remote link of key, warning on failure.
+func addRemoteLink(jiraClient *gojira.Client, key string, link *gojira.RemoteLink) {
+	if _, resp, err := jiraClient.Issue.AddRemoteLink(key, link); err != nil {
+		fmt.Printf("Warning: unable to link %s to %s: %v\n", key, link.Object.URL,
+			responseError(resp, err))
+	}
+}
+
+// SetRemoteLink adds or updates the remote link from key to the Github issue.
+func SetRemoteLink(key string, issue *github.Issue, opts ...Option) error {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return err
+		}
+	}
+	if config.noRemoteLink {
+		return nil
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return err
+	}
+
+	if _, resp, err := jiraClient.Issue.AddRemoteLink(key, remoteLink(issue)); err != nil {
+		return responseError(resp, err)
+	}
+	return nil
+}
+
+// GithubLinks returns the Github URLs the remote links of the Jira issue key
+// point at.
+func GithubLinks(key string, opts ...Option) ([]string, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteLinks, resp, err := jiraClient.Issue.GetRemoteLinks(key)
+	if err != nil {
+		return nil, responseError(resp, err)
+	}
+	var urls []string
+	if remoteLinks != nil {
+		for _, l := range *remoteLinks {
+			if (l.Application != nil && l.Application.Type == "com.github") ||
+				strings.HasPrefix(l.GlobalID, "https://github.com/") {
+				urls = append(urls, l.GlobalID)
+			}
+		}
+	}
+	return urls, nil
+}
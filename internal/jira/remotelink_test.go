@@ -0,0 +1,190 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RemoteLink", func() {
+	var (
+		originalToken string
+		ghissue       *github.Issue
+	)
+	BeforeEach(func() {
+		originalToken = os.Getenv("JIRA_TOKEN")
+		err := os.Setenv("JIRA_TOKEN", "blah-blah-blah")
+		Expect(err).NotTo(HaveOccurred())
+
+		ghissue = &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			State:  github.String("open"),
+			URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+		}
+	})
+	AfterEach(func() {
+		err := os.Setenv("JIRA_TOKEN", originalToken)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// remoteLinkHandler records the remote links posted to Jira.
+	remoteLinkHandler := func(posted *[]gojira.RemoteLink) jmock.MockBackendOption {
+		return jmock.WithRequestMatchHandler(
+			jmock.PostIssueRemoteLinkByKey,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var link gojira.RemoteLink
+				Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+				*posted = append(*posted, link)
+				w.Write(jmock.MustMarshal(gojira.RemoteLink{ID: 10000}))
+			}),
+		)
+	}
+
+	Describe("remoteLink", func() {
+		It("should link to an open Github issue", func() {
+			link := remoteLink(ghissue)
+			Expect(link.GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+			Expect(link.Relationship).To(Equal("Upstream Github issue"))
+			Expect(link.Object.URL).To(Equal("https://github.com/foo/bar/issues/123"))
+			Expect(link.Object.Title).To(Equal("foo/bar#123"))
+			Expect(link.Object.Summary).To(Equal("Issue 1"))
+			Expect(link.Object.Icon.Title).To(Equal("GitHub"))
+			Expect(link.Object.Status.Resolved).To(BeFalse())
+			Expect(link.Object.Status.Icon.Title).To(Equal("Open"))
+		})
+		It("should mark a closed pull request as resolved", func() {
+			ghissue.State = github.String("closed")
+			ghissue.PullRequestLinks = &github.PullRequestLinks{}
+			link := remoteLink(ghissue)
+			Expect(link.Relationship).To(Equal("Upstream Github pull request"))
+			Expect(link.Object.Status.Resolved).To(BeTrue())
+			Expect(link.Object.Status.Icon.Title).To(Equal("Closed"))
+		})
+	})
+
+	Describe("Clone", func() {
+		It("should add a remote link to the new issue", func() {
+			var posted []gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				remoteLinkHandler(&posted),
+			)
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posted).To(HaveLen(1))
+			Expect(posted[0].GlobalID).To(Equal("https://github.com/foo/bar/issues/123"))
+		})
+		It("should bring the remote link of an issue cloned before up to date", func() {
+			ghissue.State = github.String("closed")
+			existing := gojira.Issue{
+				Key: "OSDK-42",
+				Fields: &gojira.IssueFields{
+					Description: "Upstream Github issue: https://github.com/foo/bar/issues/123\n",
+				},
+			}
+			var posted []gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult(existing)),
+				remoteLinkHandler(&posted),
+			)
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posted).To(HaveLen(1))
+			Expect(posted[0].Object.Status.Resolved).To(BeTrue())
+		})
+		It("should not add a remote link when disabled", func() {
+			var posted []gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				remoteLinkHandler(&posted),
+			)
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+				WithRemoteLink(false),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posted).To(BeEmpty())
+		})
+		It("should still clone the issue if the remote link fails", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+			)
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithProject("OSDK"),
+				WithJiraURL("http://localhost"),
+			)
+			w.Close()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-7"))
+			Expect(string(stdout)).To(ContainSubstring("Warning: unable to link OSDK-7"))
+		})
+	})
+
+	Describe("SetRemoteLink", func() {
+		It("should post the remote link", func() {
+			var posted []gojira.RemoteLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(remoteLinkHandler(&posted))
+			err := SetRemoteLink("OSDK-42", ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posted).To(HaveLen(1))
+			Expect(posted[0].Object.Title).To(Equal("foo/bar#123"))
+		})
+		It("should return Jira's response on failure", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusForbidden)
+						w.Write([]byte(`{"errorMessages":["You do not have the permission to link issues."]}`))
+					}),
+				),
+			)
+			err := SetRemoteLink("OSDK-42", ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).To(MatchError(ContainSubstring("permission to link issues")))
+		})
+	})
+})
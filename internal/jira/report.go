@@ -0,0 +1,145 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Outcome is what Clone did with a Github issue.
+type Outcome string
+
+const (
+	Created Outcome = "created"
+	Updated Outcome = "updated"
+	Skipped Outcome = "skipped"
+	Planned Outcome = "planned"
+	Failed  Outcome = "failed"
+)
+
+// ReportEntry is the outcome of cloning one Github issue.
+type ReportEntry struct {
+	Project string
+	Number  int
+	URL     string
+	Key     string
+	Outcome Outcome
+	Err     error
+}
+
+// Report collects the outcome of every issue in a clone run. It is safe to
+// use from several goroutines.
+type Report struct {
+	lock    sync.Mutex
+	Entries []ReportEntry
+}
+
+// Add records an entry, callers use it for issues that failed before they
+// reached Clone.
+func (r *Report) Add(e ReportEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Entries = append(r.Entries, e)
+}
+
+// Count returns the number of entries with the given outcome.
+func (r *Report) Count(o Outcome) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	n := 0
+	for _, e := range r.Entries {
+		if e.Outcome == o {
+			n++
+		}
+	}
+	return n
+}
+
+// Err returns nil if every issue was cloned, the error of the issue that
+// failed if only one did, and an error counting the failures otherwise.
+// Issues reported as already cloned are not failures.
+func (r *Report) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var failed []ReportEntry
+	for _, e := range r.Entries {
+		var ace *AlreadyClonedError
+		if e.Err != nil && !errors.As(e.Err, &ace) {
+			failed = append(failed, e)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s: %w", r.ref(failed[0]), failed[0].Err)
+	}
+	return fmt.Errorf("%d of %d issues failed to clone", len(failed), len(r.Entries))
+}
+
+// ref names the issue of the entry, adding its project when the report
+// covers more than one.
+func (r *Report) ref(e ReportEntry) string {
+	for _, o := range r.Entries {
+		if o.Project != e.Project {
+			return fmt.Sprintf("%s#%d", e.Project, e.Number)
+		}
+	}
+	return fmt.Sprintf("#%d", e.Number)
+}
+
+// Print writes a summary of the run to w, followed by a table of the issues.
+func (r *Report) Print(w io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	counts := map[Outcome]int{}
+	for _, e := range r.Entries {
+		counts[e.Outcome]++
+	}
+
+	fmt.Fprintf(w, "\nCloned %d issues: %d created, %d updated, %d skipped, %d planned, %d failed\n",
+		len(r.Entries), counts[Created], counts[Updated], counts[Skipped], counts[Planned], counts[Failed])
+	if len(r.Entries) == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ISSUE\tOUTCOME\tJIRA\tERROR")
+	for _, e := range r.Entries {
+		var msg string
+		if e.Err != nil {
+			// keep the error on the line of its issue
+			msg = strings.Join(strings.Fields(e.Err.Error()), " ")
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", r.ref(e), e.Outcome, e.Key, msg)
+	}
+	tw.Flush()
+
+	// the empty cells leave trailing spaces behind
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			fmt.Fprintln(w, strings.TrimRight(line, " \n"))
+		}
+	}
+}
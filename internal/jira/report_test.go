@@ -0,0 +1,127 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/v47/github"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	It("should count the entries by outcome", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 1, Key: "OSDK-1", Outcome: Created})
+		report.Add(ReportEntry{Number: 2, Key: "OSDK-2", Outcome: Skipped})
+		report.Add(ReportEntry{Number: 3, Outcome: Failed, Err: fmt.Errorf("boom")})
+		report.Add(ReportEntry{Number: 4, Key: "OSDK-4", Outcome: Created})
+
+		Expect(report.Count(Created)).To(Equal(2))
+		Expect(report.Count(Skipped)).To(Equal(1))
+		Expect(report.Count(Failed)).To(Equal(1))
+		Expect(report.Count(Updated)).To(Equal(0))
+	})
+	It("should print a summary of the run", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 1, Key: "OSDK-1", Outcome: Created})
+		report.Add(ReportEntry{Number: 3, Outcome: Failed, Err: fmt.Errorf("boom")})
+
+		var buf bytes.Buffer
+		report.Print(&buf)
+		Expect(buf.String()).To(Equal("\nCloned 2 issues: 1 created, 0 updated, 0 skipped, 0 planned, 1 failed\n" +
+			"  ISSUE  OUTCOME  JIRA    ERROR\n" +
+			"  #1     created  OSDK-1\n" +
+			"  #3     failed           boom\n"))
+	})
+	It("should name the project when there are several", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Project: "foo/bar", Number: 1, Key: "OSDK-1", Outcome: Created})
+		report.Add(ReportEntry{Project: "foo/baz", Number: 1, Key: "OSDK-2", Outcome: Created})
+
+		var buf bytes.Buffer
+		report.Print(&buf)
+		Expect(buf.String()).To(HaveSuffix("  ISSUE      OUTCOME  JIRA    ERROR\n" +
+			"  foo/bar#1  created  OSDK-1\n" +
+			"  foo/baz#1  created  OSDK-2\n"))
+	})
+	It("should not have an error when nothing failed", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 1, Key: "OSDK-1", Outcome: Created})
+		Expect(report.Err()).To(Succeed())
+	})
+	It("should return the error of the only failure", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 1, Key: "OSDK-1", Outcome: Created})
+		report.Add(ReportEntry{Number: 3, Outcome: Failed, Err: fmt.Errorf("boom")})
+		Expect(report.Err()).To(MatchError("#3: boom"))
+	})
+	It("should count the failures", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 1, Outcome: Failed, Err: fmt.Errorf("boom")})
+		report.Add(ReportEntry{Number: 2, Key: "OSDK-2", Outcome: Skipped,
+			Err: &AlreadyClonedError{Key: "OSDK-2"}})
+		report.Add(ReportEntry{Number: 3, Key: "OSDK-3", Outcome: Created})
+		report.Add(ReportEntry{Number: 4, Outcome: Failed, Err: fmt.Errorf("bang")})
+		Expect(report.Err()).To(MatchError("2 of 4 issues failed to clone"))
+	})
+	It("should not count the issues reported as already cloned", func() {
+		report := &Report{}
+		report.Add(ReportEntry{Number: 2, Key: "OSDK-2", Outcome: Skipped,
+			Err: &AlreadyClonedError{Key: "OSDK-2"}})
+		Expect(report.Err()).To(Succeed())
+	})
+	It("should be filled in by Clone", func() {
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+		)
+		ghissue := &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+		}
+
+		report := &Report{}
+		_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+			WithJiraURL("http://localhost"),
+			WithDryRun(true),
+			WithReport(report),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Entries).To(HaveLen(1))
+		Expect(report.Entries[0].Project).To(Equal("foo/bar"))
+		Expect(report.Entries[0].Number).To(Equal(123))
+		Expect(report.Entries[0].URL).To(Equal("https://github.com/foo/bar/issues/123"))
+		Expect(report.Entries[0].Outcome).To(Equal(Planned))
+	})
+	It("should record a failure to set up the Jira client", func() {
+		Expect(os.Unsetenv("GH2JIRA_MISSING_TOKEN")).To(Succeed())
+
+		report := &Report{}
+		_, err := Clone(&github.Issue{Number: github.Int(123)},
+			WithTokenEnv("GH2JIRA_MISSING_TOKEN"),
+			WithReport(report),
+		)
+		Expect(err).To(HaveOccurred())
+		Expect(report.Entries).To(HaveLen(1))
+		Expect(report.Entries[0].Outcome).To(Equal(Failed))
+		Expect(report.Err()).To(HaveOccurred())
+	})
+})
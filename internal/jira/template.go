@@ -0,0 +1,122 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/tmpl"
+)
+
+const (
+	// DefaultSummary is the summary template used when none is configured.
+	DefaultSummary = `[UPSTREAM{{ if .GetPullRequestLinks }} PR{{ end }}] {{ .GetTitle }} #{{ .GetNumber }}`
+	// DefaultDescription is the description template used when none is
+	// configured. The link to the Github issue is always added after it.
+	DefaultDescription = "{{ with .PullRequestTable }}{{ . }}\n{{ end }}{{ .GetBody | jira }}"
+)
+
+var (
+	defaultSummary     = template.Must(tmpl.Parse("summary", DefaultSummary))
+	defaultDescription = template.Must(tmpl.Parse("description", DefaultDescription))
+)
+
+// TemplateData is what the summary and description templates are executed
+// on. It embeds the Github issue, so {{ .GetTitle }} or
+// {{ .GetMilestone.GetTitle }} work as they do on a *github.Issue.
+type TemplateData struct {
+	*github.Issue
+	// Project is the ORG/REPO of the Github issue.
+	Project string
+	// WebURL is the link to the Github issue.
+	WebURL string
+	// PullRequest and Reviews are set when cloning a pull request.
+	PullRequest *github.PullRequest
+	Reviews     []*github.PullRequestReview
+}
+
+// PullRequestTable returns the Jira table describing the pull request, empty
+// when the issue is not a pull request.
+func (d TemplateData) PullRequestTable() string {
+	if d.PullRequest == nil {
+		return ""
+	}
+	return pullRequestBlock(d.PullRequest, d.Reviews)
+}
+
+// Render returns the summary and description Clone would give the Jira issue
+// cloned from the Github issue.
+func Render(issue *github.Issue, opts ...Option) (string, string, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return "", "", err
+		}
+	}
+	summary, description, err := config.render(issue)
+	if err != nil {
+		return "", "", err
+	}
+	return summary, upstreamDescription(description, getWebURL(issue.GetURL())), nil
+}
+
+// upstreamDescription adds the link to the Github issue that findExisting
+// looks for to the rendered description.
+func upstreamDescription(description string, weburl string) string {
+	return fmt.Sprintf("%s\n\nUpstream Github issue: %s\n", description, weburl)
+}
+
+// render executes the summary and description templates on the Github issue.
+// The summary is a single line.
+func (c *ClonerConfig) render(issue *github.Issue) (string, string, error) {
+	weburl := getWebURL(issue.GetURL())
+	data := TemplateData{
+		Issue:   issue,
+		Project: webURLProject(weburl),
+		WebURL:  weburl,
+	}
+	if issue.GetPullRequestLinks() != nil {
+		data.PullRequest = c.pr
+		data.Reviews = c.reviews
+	}
+
+	summaryTmpl, descriptionTmpl := c.summary, c.description
+	if summaryTmpl == nil {
+		summaryTmpl = defaultSummary
+	}
+	if descriptionTmpl == nil {
+		descriptionTmpl = defaultDescription
+	}
+
+	var buf bytes.Buffer
+	if err := summaryTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("unable to render the summary: %w", err)
+	}
+	summary := strings.Join(strings.Fields(buf.String()), " ")
+	if summary == "" {
+		return "", "", fmt.Errorf("the summary template rendered an empty summary")
+	}
+
+	buf.Reset()
+	if err := descriptionTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("unable to render the description: %w", err)
+	}
+	return summary, buf.String(), nil
+}
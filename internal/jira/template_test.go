@@ -0,0 +1,110 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+var _ = Describe("Template", func() {
+	ghissue := &github.Issue{
+		Number:    github.Int(123),
+		Title:     github.String("Fix the thing"),
+		Body:      github.String("**broken**"),
+		URL:       github.String("https://api.github.com/repos/foo/bar/issues/123"),
+		User:      &github.User{Login: github.String("jdoe")},
+		Milestone: &github.Milestone{Title: github.String("v1.25.0")},
+		Labels:    []*github.Label{{Name: github.String("kind/bug")}},
+	}
+
+	It("should render the built-in summary and description", func() {
+		summary, description, err := Render(ghissue)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(Equal("[UPSTREAM] Fix the thing #123"))
+		Expect(description).To(Equal("*broken*\n\nUpstream Github issue: https://github.com/foo/bar/issues/123\n"))
+	})
+	It("should render the given templates", func() {
+		summary, description, err := Render(ghissue, WithTemplate(
+			"[{{ .Project }}] {{ .GetTitle }}\n({{ .GetMilestone.GetTitle }})",
+			"{{ .GetBody | jira }}\n\nReported by {{ .GetUser.GetLogin }}, labels: {{ labels .Labels | join \", \" }}",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(Equal("[foo/bar] Fix the thing (v1.25.0)"))
+		Expect(description).To(Equal("*broken*\n\nReported by jdoe, labels: kind/bug\n\n" +
+			"Upstream Github issue: https://github.com/foo/bar/issues/123\n"))
+	})
+	It("should keep the built-in description when only the summary is given", func() {
+		_, description, err := Render(ghissue, WithTemplate("{{ .GetTitle }}", ""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(description).To(HavePrefix("*broken*\n"))
+	})
+	It("should give the pull request table to pull requests", func() {
+		pr := &github.PullRequest{
+			Number:  github.Int(124),
+			State:   github.String("open"),
+			HTMLURL: github.String("https://github.com/foo/bar/pull/124"),
+		}
+		_, description, err := Render(&github.Issue{
+			Number:           github.Int(124),
+			Title:            github.String("Fix"),
+			URL:              github.String("https://api.github.com/repos/foo/bar/issues/124"),
+			PullRequestLinks: &github.PullRequestLinks{},
+		}, WithPullRequest(pr, nil), WithTemplate("", "{{ .PullRequest.GetState }}"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(description).To(HavePrefix("open\n"))
+	})
+	It("should fail for an invalid template", func() {
+		_, _, err := Render(ghissue, WithTemplate("{{ .GetTitle", ""))
+		Expect(err).To(MatchError(ContainSubstring("invalid summary template")))
+	})
+	It("should fail for a template that does not execute", func() {
+		_, _, err := Render(ghissue, WithTemplate("{{ .Nope }}", ""))
+		Expect(err).To(MatchError(ContainSubstring("unable to render the summary")))
+	})
+	It("should fail for an empty summary", func() {
+		_, _, err := Render(ghissue, WithTemplate("{{ .GetAssignee.GetLogin }}", ""))
+		Expect(err).To(MatchError("the summary template rendered an empty summary"))
+	})
+	It("should be used by Clone", func() {
+		var created gojira.Issue
+		mockedHTTPClient := jmock.NewMockedHTTPClient(
+			jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+			jmock.WithRequestMatchHandler(
+				jmock.PostIssue,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+					w.Write(jmock.MustMarshal(created))
+				}),
+			),
+		)
+
+		_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+			WithJiraURL("http://localhost"),
+			WithTemplate("{{ .GetTitle | upper }}", "{{ .GetBody }}"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.Fields.Summary).To(Equal("FIX THE THING"))
+		Expect(created.Fields.Description).To(Equal(
+			"**broken**\n\nUpstream Github issue: https://github.com/foo/bar/issues/123\n"))
+	})
+})
@@ -0,0 +1,163 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+)
+
+// Users maps Github logins to Jira users. A login in Map is mapped to the
+// Jira username it names, other logins are looked up in Jira by the public
+// email Email returns for them, when Email is set. Lookups are cached, Users
+// is safe to use from several goroutines.
+type Users struct {
+	Map   map[string]string
+	Email func(login string) (string, error)
+
+	lock  sync.Mutex
+	found map[string]userMatch
+}
+
+// userMatch is the Jira user a Github login maps to, nil with the reason
+// when it maps to none.
+type userMatch struct {
+	user *gojira.User
+	how  string
+}
+
+// NewUsers returns Users mapping the logins in m, and looking up the others
+// by email when email is not nil.
+func NewUsers(m map[string]string, email func(login string) (string, error)) *Users {
+	return &Users{Map: m, Email: email}
+}
+
+// resolve returns the Jira user the Github login maps to and how it was
+// found, or a nil user and why there is none.
+func (u *Users) resolve(jiraClient *gojira.Client, login string) (*gojira.User, string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if m, ok := u.found[login]; ok {
+		return m.user, m.how, nil
+	}
+	m, err := u.lookup(jiraClient, login)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.found == nil {
+		u.found = map[string]userMatch{}
+	}
+	u.found[login] = m
+	return m.user, m.how, nil
+}
+
+func (u *Users) lookup(jiraClient *gojira.Client, login string) (userMatch, error) {
+	for gh, name := range u.Map {
+		if strings.EqualFold(gh, login) {
+			return userMatch{user: &gojira.User{Name: name}, how: "from the users map"}, nil
+		}
+	}
+	if u.Email == nil {
+		return userMatch{how: "not in the users map"}, nil
+	}
+
+	email, err := u.Email(login)
+	if err != nil {
+		return userMatch{}, fmt.Errorf("unable to get the email of Github user %s: %w", login, err)
+	}
+	if email == "" {
+		return userMatch{how: "not in the users map and has no public email"}, nil
+	}
+
+	q := url.QueryEscape(email)
+	users, resp, err := jiraClient.User.Find(q, gojira.WithUsername(q))
+	if err != nil {
+		return userMatch{}, fmt.Errorf("unable to search Jira users: %w", responseError(resp, err))
+	}
+	var matches []gojira.User
+	for _, ju := range users {
+		if strings.EqualFold(ju.EmailAddress, email) {
+			matches = append(matches, ju)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return userMatch{how: fmt.Sprintf("no Jira user has the email %s", email)}, nil
+	case 1:
+		ju := &gojira.User{Name: matches[0].Name}
+		if ju.Name == "" {
+			// Jira Cloud only knows account IDs
+			ju.AccountID = matches[0].AccountID
+		}
+		return userMatch{user: ju, how: fmt.Sprintf("by email %s", email)}, nil
+	}
+	return userMatch{how: fmt.Sprintf("%d Jira users have the email %s", len(matches), email)}, nil
+}
+
+// userNote says who a Github user was mapped to, or why it was not.
+type userNote struct {
+	text  string
+	unset bool
+}
+
+func (n userNote) String() string {
+	return n.text
+}
+
+// applyUsers sets the Jira assignee, and the reporter when asked, from the
+// Github assignee and author of the issue. It returns a note for each.
+func applyUsers(jiraClient *gojira.Client, config *ClonerConfig, ji *gojira.Issue,
+	issue *github.Issue) ([]userNote, error) {
+
+	var notes []userNote
+	set := func(role string, login string) (*gojira.User, error) {
+		user, how, err := config.users.resolve(jiraClient, login)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			notes = append(notes, userNote{
+				text:  fmt.Sprintf("%s: not set for Github user %s, %s", role, login, how),
+				unset: true,
+			})
+			return nil, nil
+		}
+		name := user.Name
+		if name == "" {
+			name = user.AccountID
+		}
+		notes = append(notes, userNote{text: fmt.Sprintf("%s: %s (Github user %s, %s)", role, name, login, how)})
+		return user, nil
+	}
+
+	var err error
+	if login := issue.GetAssignee().GetLogin(); login != "" {
+		if ji.Fields.Assignee, err = set("Assignee", login); err != nil {
+			return nil, err
+		}
+	}
+	if login := issue.GetUser().GetLogin(); login != "" && config.setReporter {
+		if ji.Fields.Reporter, err = set("Reporter", login); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
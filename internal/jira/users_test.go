@@ -0,0 +1,189 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+)
+
+var _ = Describe("Users", func() {
+	var jiraClient *gojira.Client
+
+	newClient := func(opts ...jmock.MockBackendOption) *gojira.Client {
+		cl, err := gojira.NewClient(jmock.NewMockedHTTPClient(opts...), "http://localhost")
+		Expect(err).NotTo(HaveOccurred())
+		return cl
+	}
+	emails := func(m map[string]string) func(string) (string, error) {
+		return func(login string) (string, error) {
+			if login == "broken" {
+				return "", fmt.Errorf("boom")
+			}
+			return m[login], nil
+		}
+	}
+
+	It("should map the logins in the users map", func() {
+		jiraClient = newClient()
+		users := NewUsers(map[string]string{"JDoe": "jdoe1"}, nil)
+		user, how, err := users.resolve(jiraClient, "jdoe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(Equal(&gojira.User{Name: "jdoe1"}))
+		Expect(how).To(Equal("from the users map"))
+
+		user, how, err = users.resolve(jiraClient, "other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(BeNil())
+		Expect(how).To(Equal("not in the users map"))
+	})
+	It("should look up users by email", func() {
+		searches := 0
+		jiraClient = newClient(
+			jmock.WithRequestMatchHandler(
+				jmock.GetUserSearch,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					searches++
+					Expect(r.URL.Query().Get("username")).To(Equal("jdoe+gh@example.com"))
+					w.Write(jmock.MustMarshal([]gojira.User{
+						{Name: "someone", EmailAddress: "jdoe@example.com"},
+						{Name: "jdoe1", EmailAddress: "JDoe+gh@example.com"},
+					}))
+				}),
+			),
+		)
+		users := NewUsers(nil, emails(map[string]string{"jdoe": "jdoe+gh@example.com"}))
+
+		user, how, err := users.resolve(jiraClient, "jdoe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(Equal(&gojira.User{Name: "jdoe1"}))
+		Expect(how).To(Equal("by email jdoe+gh@example.com"))
+
+		// the second time comes from the cache
+		_, _, err = users.resolve(jiraClient, "jdoe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(searches).To(Equal(1))
+	})
+	It("should say why a user is not mapped", func() {
+		jiraClient = newClient(
+			jmock.WithRequestMatch(jmock.GetUserSearch, []gojira.User{}),
+		)
+		users := NewUsers(nil, emails(map[string]string{"jdoe": "jdoe@example.com"}))
+
+		user, how, err := users.resolve(jiraClient, "jdoe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(BeNil())
+		Expect(how).To(Equal("no Jira user has the email jdoe@example.com"))
+
+		user, how, err = users.resolve(jiraClient, "private")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(BeNil())
+		Expect(how).To(Equal("not in the users map and has no public email"))
+
+		_, _, err = users.resolve(jiraClient, "broken")
+		Expect(err).To(MatchError("unable to get the email of Github user broken: boom"))
+	})
+	Context("Clone", func() {
+		ghissue := &github.Issue{
+			Number:   github.Int(123),
+			Title:    github.String("Issue 1"),
+			URL:      github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			User:     &github.User{Login: github.String("author")},
+			Assignee: &github.User{Login: github.String("jdoe")},
+		}
+
+		It("should set the assignee and reporter", func() {
+			var created gojira.Issue
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(jmock.MustMarshal(created))
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithUsers(NewUsers(map[string]string{"jdoe": "jdoe1", "author": "author1"}, nil)),
+				WithReporter(true),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Fields.Assignee.Name).To(Equal("jdoe1"))
+			Expect(created.Fields.Reporter.Name).To(Equal("author1"))
+		})
+		It("should leave the reporter alone unless asked", func() {
+			var created gojira.Issue
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(jmock.MustMarshal(created))
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithUsers(NewUsers(map[string]string{"jdoe": "jdoe1", "author": "author1"}, nil)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Fields.Assignee.Name).To(Equal("jdoe1"))
+			Expect(created.Fields.Reporter).To(BeNil())
+		})
+		It("should print the unmapped users in a dry run", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, searchResult()),
+			)
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+					WithJiraURL("http://localhost"),
+					WithDryRun(true),
+					WithUsers(NewUsers(map[string]string{"author": "author1"}, nil)),
+					WithReporter(true),
+				)
+				w.Close()
+				Expect(err).NotTo(HaveOccurred())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring(
+				"Assignee: not set for Github user jdoe, not in the users map\n"))
+			Expect(string(stdout)).To(ContainSubstring(
+				"Reporter: author1 (Github user author, from the users map)\n"))
+		})
+	})
+})
@@ -0,0 +1,298 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira2gh
+
+import (
+	"fmt"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/markup"
+	"github.com/jmrodri/gh2jira/internal/rules"
+)
+
+type Option func(*Config) error
+
+type Config struct {
+	dryRun     bool
+	jiraURL    string
+	rules      []rules.Rule
+	labels     []string
+	milestone  string
+	links      links.Store
+	githubOpts []gh.Option
+	jiraOpts   []jira.Option
+}
+
+func WithDryRun(dr bool) Option {
+	return func(c *Config) error {
+		c.dryRun = dr
+		return nil
+	}
+}
+
+// WithJiraURL sets the Jira instance the issues are read from, it is also
+// used to link back to them from Github.
+func WithJiraURL(u string) Option {
+	return func(c *Config) error {
+		c.jiraURL = u
+		c.jiraOpts = append(c.jiraOpts, jira.WithJiraURL(u))
+		return nil
+	}
+}
+
+// WithRules maps the Jira issue type, priority, labels and components back to
+// Github labels, see rules.GithubLabels.
+func WithRules(rs []rules.Rule) Option {
+	return func(c *Config) error {
+		c.rules = rs
+		return nil
+	}
+}
+
+// WithLabels adds labels to every Github issue created.
+func WithLabels(labels []string) Option {
+	return func(c *Config) error {
+		c.labels = labels
+		return nil
+	}
+}
+
+// WithMilestone sets the milestone by title or number, "none" for none.
+func WithMilestone(m string) Option {
+	return func(c *Config) error {
+		c.milestone = m
+		return nil
+	}
+}
+
+// WithLinks records the link between the Jira issue and the new Github issue
+// in the store.
+func WithLinks(store links.Store) Option {
+	return func(c *Config) error {
+		c.links = store
+		return nil
+	}
+}
+
+// WithGithubOptions adds Github options.
+func WithGithubOptions(opts ...gh.Option) Option {
+	return func(c *Config) error {
+		c.githubOpts = append(c.githubOpts, opts...)
+		return nil
+	}
+}
+
+// WithJiraOptions adds Jira options.
+func WithJiraOptions(opts ...jira.Option) Option {
+	return func(c *Config) error {
+		c.jiraOpts = append(c.jiraOpts, opts...)
+		return nil
+	}
+}
+
+// Clone creates a Github issue from the Jira issue key and links it back.
+func Clone(key string, opts ...Option) (*github.Issue, error) {
+	config := Config{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	ji, err := jira.GetIssue(key, config.jiraOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if ji.Fields == nil {
+		return nil, fmt.Errorf("%s has no fields", key)
+	}
+	if upstream, err := config.linked(ji); err != nil || upstream != "" {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s is already linked to %s", key, upstream)
+	}
+
+	req := config.request(ji)
+	milestone, number, err := config.resolveMilestone(ji)
+	if err != nil {
+		return nil, err
+	}
+	if number != 0 {
+		req.Milestone = github.Int(number)
+	}
+
+	if config.dryRun {
+		fmt.Println("\n############# DRY RUN MODE #############")
+		fmt.Printf("Would create a Github issue from %s\n", ji.Key)
+		fmt.Printf("Title: %s\n", req.GetTitle())
+		fmt.Printf("Labels: %s\n", strings.Join(req.GetLabels(), ", "))
+		if number != 0 {
+			fmt.Printf("Milestone: %s (#%d)\n", milestone, number)
+		}
+		fmt.Printf("Body:\n%s\n", req.GetBody())
+		fmt.Printf("\nWould link %s back to the new Github issue\n", ji.Key)
+		fmt.Println("\n############# DRY RUN MODE #############")
+		return nil, nil
+	}
+
+	issue, err := gh.CreateIssue(req, config.githubOpts...)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Created Github issue %s from %s\n", issue.GetHTMLURL(), ji.Key)
+
+	config.recordLink(ji.Key, issue)
+	if err := jira.LinkGithub(ji.Key, issue, config.jiraOpts...); err != nil {
+		return issue, fmt.Errorf("created %s but could not link %s to it: %w",
+			issue.GetHTMLURL(), ji.Key, err)
+	}
+	return issue, nil
+}
+
+// linked returns the Github issue ji is already linked to, or "".
+func (c *Config) linked(ji *gojira.Issue) (string, error) {
+	if upstream, ok := jira.UpstreamURL(ji.Fields.Description); ok {
+		return upstream, nil
+	}
+	if c.links != nil {
+		found, err := c.links.Find("", ji.Key)
+		if err != nil {
+			return "", err
+		}
+		if len(found) > 0 {
+			return found[0].Github, nil
+		}
+	}
+	urls, err := jira.GithubLinks(ji.Key, c.jiraOpts...)
+	if err != nil {
+		return "", err
+	}
+	if len(urls) > 0 {
+		return urls[0], nil
+	}
+	return "", nil
+}
+
+// recordLink adds the link between the Jira issue key and the Github issue to
+// the link store.
+func (c *Config) recordLink(key string, issue *github.Issue) {
+	if c.links == nil {
+		return
+	}
+	ref, err := gh.ParseIssueRef(issue.GetHTMLURL())
+	if err == nil {
+		_, err = c.links.Add(links.Link{
+			Github: links.GithubRef(ref.Project, ref.Number),
+			Jira:   key,
+		})
+	}
+	if err != nil {
+		fmt.Printf("Warning: unable to record the link of %s in the link store: %v\n", key, err)
+	}
+}
+
+// request builds the Github issue for the Jira issue, without the milestone.
+func (c *Config) request(ji *gojira.Issue) *github.IssueRequest {
+	f := ji.Fields
+
+	var priority string
+	if f.Priority != nil {
+		priority = f.Priority.Name
+	}
+	components := make([]string, 0, len(f.Components))
+	for _, comp := range f.Components {
+		components = append(components, comp.Name)
+	}
+
+	labels := rules.GithubLabels(c.rules, f.Type.Name, priority, f.Labels, components)
+	for _, l := range c.labels {
+		if !contains(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+
+	body := strings.TrimSpace(markup.ToMarkdown(f.Description))
+	link := fmt.Sprintf("Cloned from Jira issue [%s](%s)", ji.Key, jira.BrowseURL(c.jiraURL, ji.Key))
+	if body == "" {
+		body = link
+	} else {
+		body = fmt.Sprintf("%s\n\n%s", body, link)
+	}
+
+	return &github.IssueRequest{
+		Title:  github.String(strings.TrimSpace(f.Summary)),
+		Body:   github.String(body),
+		Labels: &labels,
+	}
+}
+
+// resolveMilestone returns the milestone for ji, matching fix versions exactly.
+func (c *Config) resolveMilestone(ji *gojira.Issue) (string, int, error) {
+	if strings.TrimSpace(c.milestone) == "none" {
+		return "", 0, nil
+	}
+	if c.milestone != "" {
+		num, err := gh.ResolveMilestone(c.milestoneOptions(c.milestone)...)
+		return c.milestone, num, err
+	}
+
+	var (
+		milestones []*github.Milestone
+		listed     bool
+	)
+	for _, v := range ji.Fields.FixVersions {
+		if v == nil || strings.TrimSpace(v.Name) == "" {
+			continue
+		}
+		if !listed {
+			var err error
+			if milestones, err = gh.ListMilestones(c.githubOpts...); err != nil {
+				return "", 0, err
+			}
+			listed = true
+		}
+		var matches []*github.Milestone
+		for _, ms := range milestones {
+			if strings.EqualFold(ms.GetTitle(), strings.TrimSpace(v.Name)) {
+				matches = append(matches, ms)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0].GetTitle(), matches[0].GetNumber(), nil
+		}
+	}
+	return "", 0, nil
+}
+
+func (c *Config) milestoneOptions(m string) []gh.Option {
+	opts := append([]gh.Option{}, c.githubOpts...)
+	return append(opts, gh.WithMilestone(m))
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira2gh
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/links"
+	"github.com/jmrodri/gh2jira/internal/rules"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func jiraIssue() gojira.Issue {
+	return gojira.Issue{
+		Key: "OSDK-7",
+		Fields: &gojira.IssueFields{
+			Summary:     "Support Go 1.19",
+			Description: "h2. Details\nBump *go* to {{1.19}}",
+			Type:        gojira.IssueType{Name: "Bug"},
+			Priority:    &gojira.Priority{Name: "Major"},
+			Labels:      []string{"upstream"},
+			FixVersions: []*gojira.FixVersion{{Name: "v2.0.0"}, {Name: "v1.25.0"}},
+		},
+	}
+}
+
+var _ = Describe("Jira2gh", func() {
+	var (
+		originalTokens map[string]string
+	)
+	BeforeEach(func() {
+		originalTokens = map[string]string{}
+		for _, env := range []string{"GITHUB_TOKEN", "JIRA_TOKEN"} {
+			originalTokens[env] = os.Getenv(env)
+			Expect(os.Setenv(env, "blah-blah-blah")).To(Succeed())
+		}
+	})
+	AfterEach(func() {
+		for env, value := range originalTokens {
+			Expect(os.Setenv(env, value)).To(Succeed())
+		}
+	})
+
+	Describe("request", func() {
+		It("should convert the Jira issue to a Github issue", func() {
+			ji := jiraIssue()
+			config := Config{
+				jiraURL: "https://issues.redhat.com",
+				rules: []rules.Rule{
+					{Label: "kind/bug", IssueType: "Bug"},
+					{Label: "priority/important-soon", Priority: "Critical"},
+					{Label: "area/*", IssueType: "Bug"},
+				},
+				labels: []string{"triage/needs-information", "kind/bug"},
+			}
+			req := config.request(&ji)
+			Expect(req.GetTitle()).To(Equal("Support Go 1.19"))
+			Expect(req.GetBody()).To(Equal("## Details\nBump **go** to `1.19`\n\n" +
+				"Cloned from Jira issue [OSDK-7](https://issues.redhat.com/browse/OSDK-7)"))
+			Expect(req.GetLabels()).To(Equal([]string{"kind/bug", "triage/needs-information"}))
+		})
+	})
+
+	Describe("resolveMilestone", func() {
+		config := func() *Config {
+			return &Config{githubOpts: []gh.Option{
+				gh.WithClient(mock.NewMockedHTTPClient(
+					mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+						[]github.Milestone{
+							{Number: github.Int(47), Title: github.String("v1.25.0")},
+							{Number: github.Int(48), Title: github.String("v1.26.0")},
+							{Number: github.Int(49), Title: github.String("V1.26.0")},
+						},
+					),
+				)),
+				gh.WithProject("operator-framework/operator-sdk"),
+			}}
+		}
+		fixVersions := func(names ...string) *gojira.Issue {
+			ji := &gojira.Issue{Fields: &gojira.IssueFields{}}
+			for _, n := range names {
+				ji.Fields.FixVersions = append(ji.Fields.FixVersions, &gojira.FixVersion{Name: n})
+			}
+			return ji
+		}
+		It("should only use milestones whose title is the fix version", func() {
+			title, num, err := config().resolveMilestone(fixVersions("v1.2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(title).To(BeEmpty())
+			Expect(num).To(BeZero())
+		})
+		It("should move on from a fix version matching several milestones", func() {
+			title, num, err := config().resolveMilestone(fixVersions("v1.26.0", "V1.25.0"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(title).To(Equal("v1.25.0"))
+			Expect(num).To(Equal(47))
+		})
+	})
+
+	Describe("Clone", func() {
+		var (
+			milestones *http.Client
+		)
+		BeforeEach(func() {
+			milestones = mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+					[]github.Milestone{
+						{Number: github.Int(47), Title: github.String("v1.25.0")},
+					},
+					[]github.Milestone{
+						{Number: github.Int(47), Title: github.String("v1.25.0")},
+					},
+				),
+			)
+		})
+		It("should refuse an issue that is already linked", func() {
+			ji := jiraIssue()
+			ji.Fields.Description = "body\n\nUpstream Github issue: " +
+				"https://github.com/foo/bar/issues/123\n"
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, ji),
+			)
+			_, err := Clone("OSDK-7",
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).To(MatchError("OSDK-7 is already linked to https://github.com/foo/bar/issues/123"))
+		})
+		It("should refuse an issue linked in the link store", func() {
+			dir, err := os.MkdirTemp("", "gh2jira-links")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := links.Open("json", filepath.Join(dir, "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = store.Add(links.Link{Github: "foo/bar#123", Jira: "OSDK-7"})
+			Expect(err).NotTo(HaveOccurred())
+
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue()),
+			)
+			_, err = Clone("OSDK-7",
+				WithLinks(store),
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).To(MatchError("OSDK-7 is already linked to foo/bar#123"))
+		})
+		It("should refuse an issue with a remote link to Github", func() {
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue()),
+				jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{
+					{GlobalID: "https://example.com/docs"},
+					{
+						GlobalID:    "https://github.com/foo/bar/issues/123",
+						Application: &gojira.RemoteLinkApplication{Type: "com.github", Name: "GitHub"},
+					},
+				}),
+			)
+			_, err := Clone("OSDK-7",
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).To(MatchError("OSDK-7 is already linked to https://github.com/foo/bar/issues/123"))
+		})
+		It("should record the link even when Jira can not be linked back", func() {
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.PostReposIssuesByOwnerByRepo,
+					github.Issue{
+						Number:  github.Int(5001),
+						HTMLURL: github.String("https://github.com/operator-framework/operator-sdk/issues/5001"),
+					},
+				),
+			)
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue(), jiraIssue()),
+				jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{}),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						jmock.WriteError(w, http.StatusForbidden, "no permission")
+					}),
+				),
+			)
+
+			dir, err := os.MkdirTemp("", "gh2jira-links")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := links.Open("json", filepath.Join(dir, "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			issue, err := Clone("OSDK-7",
+				WithLinks(store),
+				WithMilestone("none"),
+				WithGithubOptions(gh.WithClient(githubClient),
+					gh.WithProject("operator-framework/operator-sdk")),
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).To(MatchError(ContainSubstring("could not link OSDK-7")))
+			Expect(issue.GetNumber()).To(Equal(5001))
+			Expect(store.Find("operator-framework/operator-sdk#5001", "OSDK-7")).To(HaveLen(1))
+		})
+		It("should only print the issue in dry run mode", func() {
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue()),
+				jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{}),
+			)
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				issue, err := Clone("OSDK-7",
+					WithDryRun(true),
+					WithGithubOptions(gh.WithClient(milestones),
+						gh.WithProject("operator-framework/operator-sdk")),
+					WithJiraOptions(jira.WithClient(jiraClient)),
+					WithJiraURL("http://localhost"),
+				)
+				w.Close()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(issue).To(BeNil())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring("DRY RUN MODE"))
+			Expect(string(stdout)).To(ContainSubstring("Title: Support Go 1.19\n"))
+			// v2.0.0 has no milestone, the next fix version does
+			Expect(string(stdout)).To(ContainSubstring("Milestone: v1.25.0 (#47)\n"))
+			Expect(string(stdout)).To(ContainSubstring("Would link OSDK-7"))
+		})
+		It("should create the Github issue and link the Jira issue to it", func() {
+			var created github.IssueRequest
+			var linked gojira.Issue
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo,
+					[]github.Milestone{
+						{Number: github.Int(47), Title: github.String("v1.25.0")},
+					},
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write(mock.MustMarshal(github.Issue{
+							Number:  github.Int(5001),
+							HTMLURL: github.String("https://github.com/operator-framework/operator-sdk/issues/5001"),
+						}))
+					}),
+				),
+			)
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue(), jiraIssue()),
+				jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{}),
+				jmock.WithRequestMatchHandler(
+					jmock.PutIssueByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&linked)).To(Succeed())
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+				jmock.WithRequestMatch(jmock.PostIssueCommentByKey, gojira.Comment{ID: "1"}),
+			)
+
+			dir, err := os.MkdirTemp("", "gh2jira-links")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := links.Open("json", filepath.Join(dir, "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			issue, err := Clone("OSDK-7",
+				WithLinks(store),
+				WithMilestone("v1.25.0"),
+				WithGithubOptions(gh.WithClient(githubClient),
+					gh.WithProject("operator-framework/operator-sdk")),
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue.GetNumber()).To(Equal(5001))
+			Expect(created.GetMilestone()).To(Equal(47))
+			Expect(created.GetTitle()).To(Equal("Support Go 1.19"))
+			Expect(linked.Fields.Description).To(ContainSubstring(
+				"Upstream Github issue: https://github.com/operator-framework/operator-sdk/issues/5001"))
+			Expect(store.Find("operator-framework/operator-sdk#5001", "OSDK-7")).To(HaveLen(1))
+		})
+		It("should return an error for an unknown milestone", func() {
+			jiraClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetIssueByKey, jiraIssue()),
+				jmock.WithRequestMatch(jmock.GetIssueRemoteLinkByKey, []gojira.RemoteLink{}),
+			)
+			_, err := Clone("OSDK-7",
+				WithMilestone("v9"),
+				WithGithubOptions(gh.WithClient(milestones),
+					gh.WithProject("operator-framework/operator-sdk")),
+				WithJiraOptions(jira.WithClient(jiraClient)),
+				WithJiraURL("http://localhost"),
+			)
+			Expect(err).To(MatchError(gh.ErrNoMilestone))
+		})
+	})
+})
@@ -0,0 +1,292 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package links
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileVersion is the version of the link file format.
+const FileVersion = 1
+
+// Backends lists the valid backends of Open.
+var Backends = []string{"json", "yaml"}
+
+// Link records that a Github issue was cloned to, or linked with, a Jira
+// issue.
+type Link struct {
+	// Github is the Github issue as ORG/REPO#NUMBER.
+	Github string `json:"github" yaml:"github"`
+	// Jira is the key of the Jira issue.
+	Jira    string    `json:"jira" yaml:"jira"`
+	Created time.Time `json:"created" yaml:"created"`
+}
+
+func (l Link) String() string {
+	return fmt.Sprintf("%s -> %s", l.Github, l.Jira)
+}
+
+// matches returns true if the link is for the Github issue and the Jira key,
+// an empty one matches any.
+func (l Link) matches(github string, jira string) bool {
+	return (github == "" || strings.EqualFold(l.Github, github)) &&
+		(jira == "" || strings.EqualFold(l.Jira, jira))
+}
+
+// GithubRef returns the ORG/REPO#NUMBER form of a Github issue used by links.
+func GithubRef(project string, number int) string {
+	return fmt.Sprintf("%s#%d", project, number)
+}
+
+// SplitGithubRef returns the ORG/REPO and number of a Github issue in the
+// form returned by GithubRef.
+func SplitGithubRef(ref string) (string, int, error) {
+	project, num, ok := strings.Cut(ref, "#")
+	if !ok || strings.Count(project, "/") != 1 {
+		return "", 0, fmt.Errorf("invalid Github issue %q, expected ORG/REPO#NUMBER", ref)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("invalid Github issue %q, expected ORG/REPO#NUMBER", ref)
+	}
+	return project, n, nil
+}
+
+// Store records which Github issue became which Jira issue. A Github issue
+// may be linked to several Jira issues, e.g. in different projects, and the
+// other way around.
+type Store interface {
+	// Find returns the links of the Github issue and the Jira key, an empty
+	// one matches any, sorted by Github issue.
+	Find(github string, jira string) ([]Link, error)
+	// Add records the link, it returns false if it was already recorded.
+	Add(l Link) (bool, error)
+	// Remove deletes the links Find would return and returns them.
+	Remove(github string, jira string) ([]Link, error)
+}
+
+// DefaultPath returns the location of the link file of the backend when none
+// is given, typically ~/.config/gh2jira/links.json.
+func DefaultPath(backend string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh2jira", "links."+backend), nil
+}
+
+// Open returns the Store of the backend, one of Backends, kept in the file at
+// path. The json backend is meant for a local file, the yaml backend for a
+// file committed to a git repository shared by the team: it is written sorted
+// with one key per line so that changes diff and merge cleanly. If path is
+// empty the DefaultPath of the backend is used. A missing file is an empty
+// store, it is created on the first change.
+func Open(backend string, path string) (Store, error) {
+	if backend == "" {
+		backend = "json"
+	}
+	valid := false
+	for _, b := range Backends {
+		valid = valid || b == backend
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid link store backend %q, must be one of %s", backend,
+			strings.Join(Backends, ", "))
+	}
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(backend); err != nil {
+			return nil, err
+		}
+	}
+	return &fileStore{path: path, yaml: backend == "yaml"}, nil
+}
+
+// linkFile is the content of a link file.
+type linkFile struct {
+	Version int    `json:"version" yaml:"version"`
+	Links   []Link `json:"links" yaml:"links"`
+}
+
+// fileStore keeps the links in a JSON or YAML file. The file is read on every
+// call so that several gh2jira runs, or a git pull, are picked up.
+type fileStore struct {
+	lock sync.Mutex
+	path string
+	yaml bool
+}
+
+func (s *fileStore) Find(github string, jira string) ([]Link, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var found []Link
+	for _, l := range all {
+		if l.matches(github, jira) {
+			found = append(found, l)
+		}
+	}
+	sortLinks(found)
+	return found, nil
+}
+
+func (s *fileStore) Add(l Link) (bool, error) {
+	if l.Github == "" || l.Jira == "" {
+		return false, fmt.Errorf("a link needs both a Github issue and a Jira key")
+	}
+	if _, _, err := SplitGithubRef(l.Github); err != nil {
+		return false, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range all {
+		if existing.matches(l.Github, l.Jira) {
+			return false, nil
+		}
+	}
+	if l.Created.IsZero() {
+		l.Created = time.Now().UTC().Truncate(time.Second)
+	}
+	return true, s.write(append(all, l))
+}
+
+func (s *fileStore) Remove(github string, jira string) ([]Link, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var kept, removed []Link
+	for _, l := range all {
+		if l.matches(github, jira) {
+			removed = append(removed, l)
+		} else {
+			kept = append(kept, l)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, s.write(kept)
+}
+
+func (s *fileStore) read() ([]Link, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var f linkFile
+	if s.yaml {
+		err = yaml.Unmarshal(data, &f)
+	} else {
+		err = json.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid link file %s: %w", s.path, err)
+	}
+	if f.Version != FileVersion {
+		return nil, fmt.Errorf("link file %s has version %d, this gh2jira reads version %d",
+			s.path, f.Version, FileVersion)
+	}
+	return f.Links, nil
+}
+
+// write replaces the file with the links, sorted by Github issue and Jira
+// key. The file is renamed into place so that a failed write does not lose
+// the links.
+func (s *fileStore) write(all []Link) error {
+	sortLinks(all)
+	f := linkFile{Version: FileVersion, Links: all}
+	if f.Links == nil {
+		f.Links = []Link{}
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if s.yaml {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(&f)
+		data = buf.Bytes()
+	} else {
+		data, err = json.MarshalIndent(&f, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func sortLinks(all []Link) {
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Github != all[j].Github {
+			return all[i].Github < all[j].Github
+		}
+		return all[i].Jira < all[j].Jira
+	})
+}
@@ -0,0 +1,172 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package links
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Links", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "gh2jira-links")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("SplitGithubRef", func() {
+		It("should split a Github issue", func() {
+			project, num, err := SplitGithubRef(GithubRef("foo/bar", 12))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project).To(Equal("foo/bar"))
+			Expect(num).To(Equal(12))
+		})
+		It("should return an error for an invalid Github issue", func() {
+			for _, ref := range []string{"foo#1", "foo/bar", "foo/bar#x", "foo/bar/baz#1"} {
+				_, _, err := SplitGithubRef(ref)
+				Expect(err).To(HaveOccurred(), ref)
+			}
+		})
+	})
+
+	Describe("Open", func() {
+		It("should return an error for an unknown backend", func() {
+			_, err := Open("sqlite", filepath.Join(dir, "links.db"))
+			Expect(err).To(MatchError(ContainSubstring(`invalid link store backend "sqlite"`)))
+		})
+		It("should treat a missing file as an empty store", func() {
+			store, err := Open("json", filepath.Join(dir, "missing", "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Find("", "")).To(BeEmpty())
+		})
+	})
+
+	for _, backend := range Backends {
+		backend := backend
+		Describe("the "+backend+" backend", func() {
+			var (
+				path  string
+				store Store
+			)
+			BeforeEach(func() {
+				path = filepath.Join(dir, "sub", "links."+backend)
+				var err error
+				store, err = Open(backend, path)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should add and find links", func() {
+				added, err := store.Add(Link{Github: "foo/bar#2", Jira: "OSDK-2"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(added).To(BeTrue())
+				_, err = store.Add(Link{Github: "foo/bar#1", Jira: "OSDK-1"})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = store.Add(Link{Github: "foo/bar#1", Jira: "OLM-9"})
+				Expect(err).NotTo(HaveOccurred())
+
+				all, err := store.Find("", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(all).To(HaveLen(3))
+				Expect(all[0].String()).To(Equal("foo/bar#1 -> OLM-9"))
+				Expect(all[0].Created).NotTo(BeZero())
+
+				found, err := store.Find("FOO/bar#1", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(HaveLen(2))
+
+				found, err = store.Find("", "osdk-2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(HaveLen(1))
+				Expect(found[0].Github).To(Equal("foo/bar#2"))
+			})
+			It("should not add a link twice", func() {
+				created := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+				_, err := store.Add(Link{Github: "foo/bar#1", Jira: "OSDK-1", Created: created})
+				Expect(err).NotTo(HaveOccurred())
+				added, err := store.Add(Link{Github: "foo/bar#1", Jira: "OSDK-1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(added).To(BeFalse())
+
+				all, err := store.Find("", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(all).To(Equal([]Link{{Github: "foo/bar#1", Jira: "OSDK-1", Created: created}}))
+			})
+			It("should return an error for an incomplete link", func() {
+				_, err := store.Add(Link{Github: "foo/bar#1"})
+				Expect(err).To(HaveOccurred())
+			})
+			It("should remove links", func() {
+				for _, l := range []Link{
+					{Github: "foo/bar#1", Jira: "OSDK-1"},
+					{Github: "foo/bar#1", Jira: "OLM-9"},
+					{Github: "foo/bar#2", Jira: "OSDK-2"},
+				} {
+					_, err := store.Add(l)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				removed, err := store.Remove("foo/bar#1", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removed).To(HaveLen(2))
+
+				removed, err = store.Remove("", "OSDK-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(removed).To(BeEmpty())
+
+				all, err := store.Find("", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(all).To(HaveLen(1))
+				Expect(all[0].Jira).To(Equal("OSDK-2"))
+			})
+			It("should read the links written by another store", func() {
+				_, err := store.Add(Link{Github: "foo/bar#1", Jira: "OSDK-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				other, err := Open(backend, path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(other.Find("", "OSDK-1")).To(HaveLen(1))
+			})
+		})
+	}
+
+	It("should write the yaml backend one key per line", func() {
+		path := filepath.Join(dir, "links.yaml")
+		store, err := Open("yaml", path)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = store.Add(Link{Github: "foo/bar#1", Jira: "OSDK-1",
+			Created: time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)})
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("version: 1\nlinks:\n" +
+			"  - github: foo/bar#1\n    jira: OSDK-1\n    created: 2022-09-01T00:00:00Z\n"))
+	})
+	It("should return an error for a file of another version", func() {
+		path := filepath.Join(dir, "links.json")
+		Expect(os.WriteFile(path, []byte(`{"version": 2, "links": []}`), 0o644)).To(Succeed())
+		store, err := Open("json", path)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = store.Find("", "")
+		Expect(err).To(MatchError(ContainSubstring("has version 2")))
+	})
+})
@@ -0,0 +1,384 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package markup converts between Github flavored Markdown and Jira wiki
+// markup.
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlCommentRE = regexp.MustCompile(`(?s)<!--.*?-->`)
+	fenceRE       = regexp.MustCompile("^(\\s*)(`{3,}|~{3,})\\s*([\\w+#.-]*)")
+	headingRE     = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	setextRE      = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	ruleRE        = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	listItemRE    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskRE        = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	tableSepRE    = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	summaryRE     = regexp.MustCompile(`(?i)^\s*<summary>(.*?)</summary>\s*$`)
+	detailsRE     = regexp.MustCompile(`(?i)^\s*</?details>\s*$`)
+
+	imageRE    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	linkRE     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	autolinkRE = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	urlRE      = regexp.MustCompile(`https?://[^\s<>()\[\]{}|]+`)
+	brRE       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	boldRE     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	italicRE   = regexp.MustCompile(`(^|[^\w*])\*(\S(?:[^*]*?\S)?)\*`)
+	strikeRE   = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	mentionRE  = regexp.MustCompile(`(^|[\s(])@([A-Za-z0-9][A-Za-z0-9-]{0,38})(/[A-Za-z0-9_.-]+)?\b`)
+	specialRE  = regexp.MustCompile(`[{}\[\]]`)
+)
+
+// jiraLanguages maps Markdown code fence languages to the languages the Jira
+// code macro knows about. Fences in any other language become noformat
+// blocks, since Jira refuses to render a code macro it has no formatter for.
+var jiraLanguages = map[string]string{
+	"bash":       "bash",
+	"c":          "c",
+	"console":    "bash",
+	"cpp":        "cpp",
+	"css":        "css",
+	"go":         "go",
+	"golang":     "go",
+	"groovy":     "groovy",
+	"html":       "html",
+	"java":       "java",
+	"javascript": "javascript",
+	"js":         "javascript",
+	"json":       "json",
+	"python":     "python",
+	"py":         "python",
+	"ruby":       "ruby",
+	"sh":         "bash",
+	"shell":      "bash",
+	"sql":        "sql",
+	"xml":        "xml",
+	"yaml":       "yaml",
+	"yml":        "yaml",
+}
+
+// listLevel is one level of a nested list, indent is the column of the list
+// marker and marker is the Jira bullet for that level.
+type listLevel struct {
+	indent int
+	marker string
+}
+
+// ToJira converts Github flavored Markdown into Jira wiki markup.
+func ToJira(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = htmlCommentRE.ReplaceAllString(md, "")
+	lines := strings.Split(md, "\n")
+
+	var (
+		out  []string
+		list []listLevel
+	)
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRE.FindStringSubmatch(line); m != nil {
+			list = nil
+			end := i + 1
+			for end < len(lines) && !closesFence(lines[end], m[2]) {
+				end++
+			}
+			out = append(out, codeBlock(m[3], lines[i+1:end], len(m[1])))
+			i = end
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			list = nil
+			// collapse runs of blank lines, e.g. around stripped comments
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		}
+
+		if detailsRE.MatchString(line) {
+			continue
+		}
+		if m := summaryRE.FindStringSubmatch(line); m != nil {
+			out = append(out, fmt.Sprintf("*%s*", inline(strings.TrimSpace(m[1]))))
+			continue
+		}
+
+		if m := headingRE.FindStringSubmatch(line); m != nil {
+			list = nil
+			out = append(out, fmt.Sprintf("h%d. %s", len(m[1]), inline(m[2])))
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			list = nil
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			out = append(out, "{quote}", ToJira(strings.Join(quoted, "\n")), "{quote}")
+			continue
+		}
+
+		if strings.Contains(line, "|") && i+1 < len(lines) && tableSepRE.MatchString(lines[i+1]) &&
+			strings.Contains(lines[i+1], "-") {
+			list = nil
+			out = append(out, tableRow(line, "||"))
+			i += 2
+			for ; i < len(lines) && strings.Contains(lines[i], "|") &&
+				strings.TrimSpace(lines[i]) != ""; i++ {
+				out = append(out, tableRow(lines[i], "|"))
+			}
+			i--
+			continue
+		}
+
+		if ruleRE.MatchString(line) {
+			list = nil
+			out = append(out, "----")
+			continue
+		}
+
+		if m := listItemRE.FindStringSubmatch(line); m != nil {
+			marker := "*"
+			if m[2][0] >= '0' && m[2][0] <= '9' {
+				marker = "#"
+			}
+			list = nest(list, len(expandTabs(m[1])), marker)
+			out = append(out, fmt.Sprintf("%s %s", bullets(list), listItem(m[3])))
+			continue
+		}
+
+		if list != nil && (line[0] == ' ' || line[0] == '\t') {
+			// continuation of the previous list item
+			out[len(out)-1] += " " + inline(strings.TrimSpace(line))
+			continue
+		}
+
+		if i+1 < len(lines) && setextRE.MatchString(lines[i+1]) {
+			level := 1
+			if strings.HasPrefix(strings.TrimSpace(lines[i+1]), "-") {
+				level = 2
+			}
+			out = append(out, fmt.Sprintf("h%d. %s", level, inline(strings.TrimSpace(line))))
+			i++
+			continue
+		}
+
+		out = append(out, inline(line))
+	}
+
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// closesFence returns true if line closes a code fence opened with fence.
+func closesFence(line string, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// codeBlock renders the lines of a fenced code block, removing the indent of
+// the opening fence from each line.
+func codeBlock(lang string, lines []string, indent int) string {
+	body := make([]string, 0, len(lines))
+	for _, l := range lines {
+		n := 0
+		for n < indent && n < len(l) && l[n] == ' ' {
+			n++
+		}
+		body = append(body, l[n:])
+	}
+
+	open, close := "{noformat}", "{noformat}"
+	if jl, ok := jiraLanguages[strings.ToLower(lang)]; ok {
+		open, close = fmt.Sprintf("{code:%s}", jl), "{code}"
+	}
+	return strings.Join(append(append([]string{open}, body...), close), "\n")
+}
+
+// nest updates the list levels for an item whose marker is at indent.
+func nest(list []listLevel, indent int, marker string) []listLevel {
+	for len(list) > 0 && list[len(list)-1].indent > indent {
+		list = list[:len(list)-1]
+	}
+	if len(list) > 0 && list[len(list)-1].indent == indent {
+		list[len(list)-1].marker = marker
+		return list
+	}
+	return append(list, listLevel{indent: indent, marker: marker})
+}
+
+func bullets(list []listLevel) string {
+	var b strings.Builder
+	for _, l := range list {
+		b.WriteString(l.marker)
+	}
+	return b.String()
+}
+
+// listItem converts the text of a list item, turning task list checkboxes
+// into ballot boxes since Jira has no checkbox markup.
+func listItem(text string) string {
+	if m := taskRE.FindStringSubmatch(text); m != nil {
+		box := "☐"
+		if m[1] != " " {
+			box = "☑"
+		}
+		return box + " " + inline(text[len(m[0]):])
+	}
+	return inline(text)
+}
+
+// tableRow converts a Markdown table row, sep is the Jira cell separator:
+// || for header cells and | for the rest.
+func tableRow(line string, sep string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	code := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case line[i] == '`':
+			code = !code
+			cell.WriteByte(line[i])
+		case line[i] == '|' && code:
+			cell.WriteString(`\|`)
+		case line[i] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	cells = append(cells, cell.String())
+
+	for i, c := range cells {
+		c = inline(strings.TrimSpace(c))
+		if c == "" {
+			c = " "
+		}
+		cells[i] = c
+	}
+	return sep + strings.Join(cells, sep) + sep
+}
+
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+// inline converts the inline Markdown of a single line: code spans, links,
+// images, emphasis and mentions. Text that Jira would otherwise read as a
+// macro or link is escaped.
+func inline(s string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "`")
+		if start < 0 {
+			break
+		}
+		ticks := 1
+		for start+ticks < len(s) && s[start+ticks] == '`' {
+			ticks++
+		}
+		delim := s[start : start+ticks]
+		end := strings.Index(s[start+ticks:], delim)
+		if end < 0 {
+			break
+		}
+		out.WriteString(text(s[:start]))
+		code := strings.TrimSpace(s[start+ticks : start+ticks+end])
+		out.WriteString("{{" + specialRE.ReplaceAllString(code, `\$0`) + "}}")
+		s = s[start+ticks+end+ticks:]
+	}
+	out.WriteString(text(s))
+	return out.String()
+}
+
+// text converts inline Markdown that holds no code spans.
+func text(s string) string {
+	if s == "" {
+		return s
+	}
+
+	// Links, images and URLs are swapped for placeholders so neither the
+	// escaping nor the emphasis rules below touch them.
+	var saved []string
+	save := func(v string) string {
+		saved = append(saved, v)
+		return fmt.Sprintf("\x00%d\x00", len(saved)-1)
+	}
+
+	s = imageRE.ReplaceAllStringFunc(s, func(m string) string {
+		sm := imageRE.FindStringSubmatch(m)
+		return save(fmt.Sprintf("!%s!", sm[2]))
+	})
+	s = linkRE.ReplaceAllStringFunc(s, func(m string) string {
+		sm := linkRE.FindStringSubmatch(m)
+		return save(fmt.Sprintf("[%s|%s]", emphasis(escape(sm[1])), sm[2]))
+	})
+	s = autolinkRE.ReplaceAllStringFunc(s, func(m string) string {
+		return save(fmt.Sprintf("[%s]", autolinkRE.FindStringSubmatch(m)[1]))
+	})
+	s = urlRE.ReplaceAllStringFunc(s, save)
+	s = mentionRE.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mentionRE.FindStringSubmatch(m)
+		if sm[3] != "" {
+			team := sm[3][1:]
+			return sm[1] + save(fmt.Sprintf("[@%s/%s|https://github.com/orgs/%s/teams/%s]",
+				sm[2], team, sm[2], team))
+		}
+		return sm[1] + save(fmt.Sprintf("[@%s|https://github.com/%s]", sm[2], sm[2]))
+	})
+	s = brRE.ReplaceAllStringFunc(s, func(string) string { return save(`\\`) })
+
+	s = emphasis(escape(s))
+
+	// a saved link can hold the placeholder of an image saved before it, so
+	// the values are restored last to first
+	for i := len(saved) - 1; i >= 0; i-- {
+		s = strings.Replace(s, fmt.Sprintf("\x00%d\x00", i), saved[i], 1)
+	}
+	return s
+}
+
+func escape(s string) string {
+	return specialRE.ReplaceAllString(s, `\$0`)
+}
+
+// emphasis converts bold, italic and strikethrough. Bold is marked with \x01
+// until italics are done, since both use asterisks.
+func emphasis(s string) string {
+	s = boldRE.ReplaceAllString(s, "\x01$1$2\x01")
+	s = italicRE.ReplaceAllString(s, "${1}_${2}_")
+	s = strikeRE.ReplaceAllString(s, "-$1-")
+	return strings.ReplaceAll(s, "\x01", "*")
+}
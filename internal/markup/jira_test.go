@@ -0,0 +1,63 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ToJira", func() {
+
+	It("should return an empty string for an empty body", func() {
+		Expect(ToJira("")).To(Equal(""))
+	})
+	It("should leave plain text untouched", func() {
+		Expect(ToJira("body of the issue")).To(Equal("body of the issue"))
+	})
+	It("should handle Windows line endings", func() {
+		Expect(ToJira("# Title\r\n\r\ntext\r\n")).To(Equal("h1. Title\n\ntext"))
+	})
+
+	Describe("golden files", func() {
+		inputs, err := filepath.Glob(filepath.Join("testdata", "*.md"))
+		if err != nil {
+			panic(err)
+		}
+
+		for _, input := range inputs {
+			input := input
+			golden := strings.TrimSuffix(input, ".md") + ".jira"
+
+			It("should convert "+filepath.Base(input), func() {
+				md, err := os.ReadFile(input)
+				Expect(err).NotTo(HaveOccurred())
+
+				actual := ToJira(string(md)) + "\n"
+				if *update {
+					Expect(os.WriteFile(golden, []byte(actual), 0o644)).To(Succeed())
+				}
+
+				expected, err := os.ReadFile(golden)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(Equal(string(expected)))
+			})
+		}
+	})
+})
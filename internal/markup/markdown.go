@@ -0,0 +1,330 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	jiraHeadingRE  = regexp.MustCompile(`^\s*h([1-6])\.\s+(.*)$`)
+	jiraBlockRE    = regexp.MustCompile(`^\s*\{(code|noformat|quote|panel)(?::([^}]*))?\}(.*)$`)
+	jiraListRE     = regexp.MustCompile(`^\s*([*#]+|-)\s+(.*)$`)
+	jiraQuoteRE    = regexp.MustCompile(`^\s*bq\.\s+(.*)$`)
+	jiraRuleRE     = regexp.MustCompile(`^\s*-{4,}\s*$`)
+	jiraMonoRE     = regexp.MustCompile(`\{\{(.+?)\}\}`)
+	jiraCodeSpanRE = regexp.MustCompile(`\{(?:code|noformat)(?::[^}]*)?\}(.+?)\{(?:code|noformat)\}`)
+	jiraImageRE    = regexp.MustCompile(`!([^!\s|]+)(?:\|[^!]*)?!`)
+	jiraLinkRE     = regexp.MustCompile(`\[(?:([^\]|]*)\|)?([^\]|]+)\]`)
+	jiraColorRE    = regexp.MustCompile(`\{color(?::[^}]*)?\}`)
+	jiraBoldRE     = regexp.MustCompile(`(^|[^\w*\\])\*(\S(?:[^*]*?\S)?)\*`)
+	jiraItalicRE   = regexp.MustCompile(`(^|[^\w\\])_(\S(?:[^_]*?\S)?)_`)
+	jiraStrikeRE   = regexp.MustCompile(`(^|[\s(])-(\S(?:[^-]*?\S)?)-($|[\s).,!?:;])`)
+	jiraUnderRE    = regexp.MustCompile(`(^|[^\w\\])\+(\S(?:[^+]*?\S)?)\+`)
+	jiraEscapeRE   = regexp.MustCompile(`\\([{}\[\]])`)
+	jiraLanguageRE = regexp.MustCompile(`^[\w+#.-]+$`)
+)
+
+// ToMarkdown converts Jira wiki markup into Github flavored Markdown.
+func ToMarkdown(wiki string) string {
+	wiki = strings.ReplaceAll(wiki, "\r\n", "\n")
+	lines := strings.Split(wiki, "\n")
+
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := jiraBlockRE.FindStringSubmatch(line); m != nil {
+			tag := m[1]
+			body, end := jiraBlock(tag, m[3], lines, i)
+			i = end
+			switch tag {
+			case "code", "noformat":
+				lang := ""
+				if tag == "code" {
+					lang = codeLanguage(m[2])
+				}
+				out = append(out, "```"+lang)
+				out = append(out, body...)
+				out = append(out, "```")
+			case "quote", "panel":
+				if title := panelTitle(m[2]); title != "" {
+					out = append(out, "> **"+inlineMarkdown(title)+"**", ">")
+				}
+				for _, q := range strings.Split(ToMarkdown(strings.Join(body, "\n")), "\n") {
+					out = append(out, strings.TrimRight("> "+q, " "))
+				}
+				// a following line would continue the quote
+				out = append(out, "")
+			}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			continue
+		}
+
+		if m := jiraHeadingRE.FindStringSubmatch(line); m != nil {
+			out = append(out, fmt.Sprintf("%s %s", strings.Repeat("#", int(m[1][0]-'0')), inlineMarkdown(m[2])))
+			continue
+		}
+
+		if m := jiraQuoteRE.FindStringSubmatch(line); m != nil {
+			out = append(out, "> "+inlineMarkdown(m[1]))
+			continue
+		}
+
+		if jiraRuleRE.MatchString(line) {
+			out = append(out, "---")
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(line), "|") {
+			var rows [][]string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, jiraCells(strings.TrimSpace(lines[i])))
+			}
+			i--
+			// a following line would become a row of the table
+			out = append(append(out, markdownTable(rows)...), "")
+			continue
+		}
+
+		if m := jiraListRE.FindStringSubmatch(line); m != nil {
+			out = append(out, markdownListItem(m[1], m[2]))
+			continue
+		}
+
+		out = append(out, inlineMarkdown(line))
+	}
+
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// jiraBlock returns the lines of the {tag} block opened on lines[start],
+// rest is what follows the opening tag on that line, and the index of the
+// line closing the block. An unclosed block runs to the end.
+func jiraBlock(tag string, rest string, lines []string, start int) ([]string, int) {
+	closing := "{" + tag + "}"
+	if idx := strings.Index(rest, closing); idx >= 0 {
+		return []string{rest[:idx]}, start
+	}
+
+	var body []string
+	if strings.TrimSpace(rest) != "" {
+		body = append(body, rest)
+	}
+	for i := start + 1; i < len(lines); i++ {
+		if idx := strings.Index(lines[i], closing); idx >= 0 {
+			if before := lines[i][:idx]; strings.TrimSpace(before) != "" {
+				body = append(body, before)
+			}
+			return body, i
+		}
+		body = append(body, lines[i])
+	}
+	return body, len(lines) - 1
+}
+
+// codeLanguage picks the language out of the {code} parameters, e.g. go or
+// language=go|title=main.go.
+func codeLanguage(params string) string {
+	for _, p := range strings.Split(params, "|") {
+		p = strings.TrimSpace(p)
+		if v := strings.TrimPrefix(p, "language="); v != p {
+			p = v
+		} else if strings.Contains(p, "=") {
+			continue
+		}
+		if jiraLanguageRE.MatchString(p) && p != "none" {
+			return strings.ToLower(p)
+		}
+	}
+	return ""
+}
+
+// panelTitle returns the title parameter of a {panel}.
+func panelTitle(params string) string {
+	for _, p := range strings.Split(params, "|") {
+		if v := strings.TrimPrefix(strings.TrimSpace(p), "title="); v != strings.TrimSpace(p) {
+			return v
+		}
+	}
+	return ""
+}
+
+// markdownListItem converts a Jira list item, markers is the Jira bullets
+// e.g. *# for a numbered item nested in a bulleted list. Ballot boxes become
+// task list checkboxes.
+func markdownListItem(markers string, text string) string {
+	if markers == "-" {
+		markers = "*"
+	}
+	indent := 0
+	for _, m := range markers[:len(markers)-1] {
+		if m == '#' {
+			indent += 3
+		} else {
+			indent += 2
+		}
+	}
+
+	bullet := "-"
+	if markers[len(markers)-1] == '#' {
+		bullet = "1."
+	} else if strings.HasPrefix(text, "☐ ") {
+		text = "[ ] " + strings.TrimPrefix(text, "☐ ")
+	} else if strings.HasPrefix(text, "☑ ") {
+		text = "[x] " + strings.TrimPrefix(text, "☑ ")
+	}
+	return fmt.Sprintf("%s%s %s", strings.Repeat(" ", indent), bullet, inlineMarkdown(text))
+}
+
+// jiraCells splits a Jira table row into its cells. Separators inside links
+// and monospace are not cell boundaries.
+func jiraCells(row string) []string {
+	var (
+		cells []string
+		cell  strings.Builder
+		depth int
+		mono  bool
+	)
+	row = strings.TrimPrefix(strings.TrimPrefix(row, "|"), "|")
+	for i := 0; i < len(row); i++ {
+		c := row[i]
+		switch {
+		case c == '\\' && i+1 < len(row):
+			cell.WriteByte(c)
+			cell.WriteByte(row[i+1])
+			i++
+		case strings.HasPrefix(row[i:], "{{") || strings.HasPrefix(row[i:], "}}"):
+			mono = !mono
+			cell.WriteString(row[i : i+2])
+			i++
+		case c == '[' && !mono:
+			depth++
+			cell.WriteByte(c)
+		case c == ']' && !mono && depth > 0:
+			depth--
+			cell.WriteByte(c)
+		case c == '|' && depth == 0 && !mono:
+			cells = append(cells, cell.String())
+			cell.Reset()
+			if i+1 < len(row) && row[i+1] == '|' {
+				i++
+			}
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	if strings.TrimSpace(cell.String()) != "" {
+		cells = append(cells, cell.String())
+	}
+	return cells
+}
+
+// markdownTable renders the rows, the first row is the header since Markdown
+// tables need one.
+func markdownTable(rows [][]string) []string {
+	width := 0
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+
+	render := func(cells []string) string {
+		out := make([]string, width)
+		for i := range out {
+			if i < len(cells) {
+				out[i] = strings.ReplaceAll(inlineMarkdown(strings.TrimSpace(cells[i])), "|", `\|`)
+			}
+		}
+		return "| " + strings.Join(out, " | ") + " |"
+	}
+
+	table := []string{render(rows[0]), "|" + strings.Repeat(" --- |", width)}
+	for _, r := range rows[1:] {
+		table = append(table, render(r))
+	}
+	return table
+}
+
+// inlineMarkdown converts the inline markup of a single line: monospace,
+// links, images and emphasis.
+func inlineMarkdown(s string) string {
+	var saved []string
+	save := func(v string) string {
+		saved = append(saved, v)
+		return fmt.Sprintf("\x00%d\x00", len(saved)-1)
+	}
+
+	s = jiraMonoRE.ReplaceAllStringFunc(s, func(m string) string {
+		code := jiraEscapeRE.ReplaceAllString(jiraMonoRE.FindStringSubmatch(m)[1], "$1")
+		return save("`" + code + "`")
+	})
+	s = jiraCodeSpanRE.ReplaceAllStringFunc(s, func(m string) string {
+		return save("`" + jiraCodeSpanRE.FindStringSubmatch(m)[1] + "`")
+	})
+	s = jiraColorRE.ReplaceAllString(s, "")
+	s = jiraImageRE.ReplaceAllStringFunc(s, func(m string) string {
+		src := jiraImageRE.FindStringSubmatch(m)[1]
+		if !strings.Contains(src, "://") {
+			return save(fmt.Sprintf("_(attachment %s)_", src))
+		}
+		return save(fmt.Sprintf("![](%s)", src))
+	})
+	s = jiraLinkRE.ReplaceAllStringFunc(s, func(m string) string {
+		sm := jiraLinkRE.FindStringSubmatch(m)
+		label, target := sm[1], sm[2]
+		switch {
+		case strings.HasPrefix(target, "~"):
+			// a Jira user, who is not known on Github
+			return save(strings.TrimPrefix(target, "~"))
+		case !strings.Contains(target, "://") && !strings.HasPrefix(target, "mailto:"):
+			// not a link, e.g. an escaped [ or a Jira anchor
+			return m
+		case label == "":
+			return save("<" + target + ">")
+		}
+		return save(fmt.Sprintf("[%s](%s)", emphasisMarkdown(label), target))
+	})
+	s = strings.ReplaceAll(s, `\\`, "<br>")
+	s = emphasisMarkdown(s)
+	s = jiraEscapeRE.ReplaceAllString(s, `\$1`)
+
+	// a saved link can hold the placeholder of an image saved before it, so
+	// the values are restored last to first
+	for i := len(saved) - 1; i >= 0; i-- {
+		s = strings.Replace(s, fmt.Sprintf("\x00%d\x00", i), saved[i], 1)
+	}
+	return s
+}
+
+// emphasisMarkdown converts bold, italic, strikethrough and underline. Bold
+// is marked with \x01 until italics are done, since Markdown uses asterisks
+// for both.
+func emphasisMarkdown(s string) string {
+	s = jiraBoldRE.ReplaceAllString(s, "${1}\x01\x01${2}\x01\x01")
+	s = jiraItalicRE.ReplaceAllString(s, "${1}_${2}_")
+	s = jiraStrikeRE.ReplaceAllString(s, "${1}~~${2}~~${3}")
+	s = jiraUnderRE.ReplaceAllString(s, "${1}<ins>${2}</ins>")
+	return strings.ReplaceAll(s, "\x01", "*")
+}
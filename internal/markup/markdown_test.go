@@ -0,0 +1,66 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ToMarkdown", func() {
+
+	It("should return an empty string for an empty description", func() {
+		Expect(ToMarkdown("")).To(Equal(""))
+	})
+	It("should leave plain text untouched", func() {
+		Expect(ToMarkdown("description of the issue")).To(Equal("description of the issue"))
+	})
+	It("should handle Windows line endings", func() {
+		Expect(ToMarkdown("h1. Title\r\n\r\ntext\r\n")).To(Equal("# Title\n\ntext"))
+	})
+	It("should read back the task lists ToJira writes", func() {
+		Expect(ToMarkdown(ToJira("- [ ] todo\n- [x] done"))).To(Equal("- [ ] todo\n- [x] done"))
+	})
+
+	Describe("golden files", func() {
+		inputs, err := filepath.Glob(filepath.Join("testdata", "jira", "*.jira"))
+		if err != nil {
+			panic(err)
+		}
+
+		for _, input := range inputs {
+			input := input
+			golden := strings.TrimSuffix(input, ".jira") + ".md"
+
+			It("should convert "+filepath.Base(input), func() {
+				wiki, err := os.ReadFile(input)
+				Expect(err).NotTo(HaveOccurred())
+
+				actual := ToMarkdown(string(wiki)) + "\n"
+				if *update {
+					Expect(os.WriteFile(golden, []byte(actual), 0o644)).To(Succeed())
+				}
+
+				expected, err := os.ReadFile(golden)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(Equal(string(expected)))
+			})
+		}
+	})
+})
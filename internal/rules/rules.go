@@ -0,0 +1,172 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules maps Github labels to the fields of a cloned Jira issue.
+package rules
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Rule sets Jira fields on issues carrying a matching Github label. Label is
+// either an exact label or a glob such as area/*.
+type Rule struct {
+	Label      string   `yaml:"label"`
+	Priority   string   `yaml:"priority,omitempty"`
+	IssueType  string   `yaml:"issueType,omitempty"`
+	Labels     []string `yaml:"labels,omitempty"`
+	Components []string `yaml:"components,omitempty"`
+}
+
+// Validate returns an error if the rule has no valid label pattern or does
+// not set anything.
+func (r Rule) Validate() error {
+	if r.Label == "" {
+		return fmt.Errorf("rule is missing a label")
+	}
+	if _, err := path.Match(r.Label, ""); err != nil {
+		return fmt.Errorf("rule %q: invalid label pattern: %w", r.Label, err)
+	}
+	if r.Priority == "" && r.IssueType == "" && len(r.Labels) == 0 && len(r.Components) == 0 {
+		return fmt.Errorf("rule %q does not set priority, issueType, labels or components", r.Label)
+	}
+	return nil
+}
+
+// matches returns true if the Github label matches the rule.
+func (r Rule) matches(label string) bool {
+	if strings.EqualFold(r.Label, label) {
+		return true
+	}
+	ok, _ := path.Match(r.Label, label)
+	return ok
+}
+
+// Match records a rule that fired for a Github label.
+type Match struct {
+	Rule        Rule
+	GithubLabel string
+}
+
+func (m Match) String() string {
+	var set []string
+	if m.Rule.IssueType != "" {
+		set = append(set, fmt.Sprintf("issue type %s", m.Rule.IssueType))
+	}
+	if m.Rule.Priority != "" {
+		set = append(set, fmt.Sprintf("priority %s", m.Rule.Priority))
+	}
+	if len(m.Rule.Labels) > 0 {
+		set = append(set, fmt.Sprintf("labels %s", strings.Join(m.Rule.Labels, ", ")))
+	}
+	if len(m.Rule.Components) > 0 {
+		set = append(set, fmt.Sprintf("components %s", strings.Join(m.Rule.Components, ", ")))
+	}
+	return fmt.Sprintf("%s (rule %s): %s", m.GithubLabel, m.Rule.Label, strings.Join(set, "; "))
+}
+
+// Result holds the Jira fields set by the rules that fired. Empty values
+// were not set by any rule.
+type Result struct {
+	Priority   string
+	IssueType  string
+	Labels     []string
+	Components []string
+	Matches    []Match
+}
+
+// Apply runs the rules against the Github labels. Rules are checked in order
+// and the first rule to set the priority or issue type wins, while Jira
+// labels and components from every matching rule are combined.
+func Apply(rules []Rule, labels []string) Result {
+	var result Result
+	for _, r := range rules {
+		for _, label := range labels {
+			if !r.matches(label) {
+				continue
+			}
+			result.Matches = append(result.Matches, Match{Rule: r, GithubLabel: label})
+			if result.Priority == "" {
+				result.Priority = r.Priority
+			}
+			if result.IssueType == "" {
+				result.IssueType = r.IssueType
+			}
+			result.Labels = appendUnique(result.Labels, r.Labels...)
+			result.Components = appendUnique(result.Components, r.Components...)
+			break
+		}
+	}
+	return result
+}
+
+// GithubLabels returns the Github labels whose rules match a Jira issue with
+// the given fields, the reverse of Apply. A rule matches when its label is
+// not a glob and the Jira issue has everything the rule sets.
+func GithubLabels(rules []Rule, issueType string, priority string, labels []string,
+	components []string) []string {
+
+	var result []string
+	for _, r := range rules {
+		if strings.ContainsAny(r.Label, "*?[") {
+			continue
+		}
+		if r.IssueType != "" && !strings.EqualFold(r.IssueType, issueType) {
+			continue
+		}
+		if r.Priority != "" && !strings.EqualFold(r.Priority, priority) {
+			continue
+		}
+		if !containsAll(labels, r.Labels) || !containsAll(components, r.Components) {
+			continue
+		}
+		result = appendUnique(result, r.Label)
+	}
+	return result
+}
+
+// containsAll returns true if every one of values is in list, ignoring case.
+func containsAll(list []string, values []string) bool {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if strings.EqualFold(l, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
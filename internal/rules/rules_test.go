@@ -0,0 +1,96 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rules", func() {
+	var (
+		rules = []Rule{
+			{Label: "kind/bug", IssueType: "Bug"},
+			{Label: "kind/feature", IssueType: "Story"},
+			{Label: "priority/important-soon", Priority: "Urgent"},
+			{Label: "priority/important-longterm", Priority: "Medium"},
+			{Label: "area/*", Labels: []string{"upstream"}, Components: []string{"SDK"}},
+			{Label: "area/helm", Labels: []string{"helm", "upstream"}, Components: []string{"Helm"}},
+		}
+	)
+
+	Describe("Validate", func() {
+		It("should accept a rule that sets a field", func() {
+			Expect(Rule{Label: "kind/bug", IssueType: "Bug"}.Validate()).To(Succeed())
+		})
+		It("should reject a rule without a label", func() {
+			Expect(Rule{IssueType: "Bug"}.Validate()).NotTo(Succeed())
+		})
+		It("should reject a rule that sets nothing", func() {
+			Expect(Rule{Label: "kind/bug"}.Validate()).NotTo(Succeed())
+		})
+		It("should reject an invalid glob", func() {
+			Expect(Rule{Label: "area/[", Priority: "Major"}.Validate()).NotTo(Succeed())
+		})
+	})
+
+	Describe("Apply", func() {
+		It("should set nothing when no rule matches", func() {
+			result := Apply(rules, []string{"documentation"})
+			Expect(result.IssueType).To(BeEmpty())
+			Expect(result.Priority).To(BeEmpty())
+			Expect(result.Matches).To(BeEmpty())
+		})
+		It("should map kind labels to issue types", func() {
+			Expect(Apply(rules, []string{"kind/bug"}).IssueType).To(Equal("Bug"))
+			Expect(Apply(rules, []string{"kind/feature"}).IssueType).To(Equal("Story"))
+		})
+		It("should match labels ignoring case", func() {
+			Expect(Apply(rules, []string{"Kind/Bug"}).IssueType).To(Equal("Bug"))
+		})
+		It("should let the first matching rule win", func() {
+			result := Apply(rules, []string{"priority/important-longterm", "priority/important-soon"})
+			Expect(result.Priority).To(Equal("Urgent"))
+			Expect(result.Matches).To(HaveLen(2))
+		})
+		It("should combine labels and components of every rule", func() {
+			result := Apply(rules, []string{"area/helm", "kind/bug"})
+			Expect(result.IssueType).To(Equal("Bug"))
+			Expect(result.Labels).To(Equal([]string{"upstream", "helm"}))
+			Expect(result.Components).To(Equal([]string{"SDK", "Helm"}))
+		})
+		It("should describe the rules that fired", func() {
+			result := Apply(rules, []string{"area/ansible"})
+			Expect(result.Matches).To(HaveLen(1))
+			Expect(result.Matches[0].String()).To(Equal(
+				"area/ansible (rule area/*): labels upstream; components SDK"))
+		})
+	})
+	Describe("GithubLabels", func() {
+		rs := []Rule{
+			{Label: "kind/bug", IssueType: "Bug"},
+			{Label: "priority/critical-urgent", Priority: "Blocker"},
+			{Label: "area/*", Components: []string{"SDK"}},
+			{Label: "area/helm", Labels: []string{"helm"}, Components: []string{"SDK"}},
+		}
+		It("should return the labels of the matching rules", func() {
+			Expect(GithubLabels(rs, "bug", "Blocker", []string{"Helm"}, []string{"SDK"})).To(
+				Equal([]string{"kind/bug", "priority/critical-urgent", "area/helm"}))
+		})
+		It("should skip rules with a glob or a field the issue lacks", func() {
+			Expect(GithubLabels(rs, "Story", "Major", nil, []string{"SDK"})).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,315 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/links"
+)
+
+// Direction decides which side wins when a Github issue and its Jira clone
+// disagree.
+type Direction string
+
+const (
+	// Both lets the most recently updated side win. Any update counts, such
+	// as a comment, not just a change of state.
+	Both Direction = "both"
+	// FromGithub makes the Jira clone follow the Github issue. It is the
+	// default, Github being upstream.
+	FromGithub Direction = "github"
+	// FromJira makes the Github issue follow the Jira clone.
+	FromJira Direction = "jira"
+)
+
+// Directions lists the valid Direction values.
+var Directions = []Direction{Both, FromGithub, FromJira}
+
+// Mapping relates Github issue states to Jira statuses.
+type Mapping struct {
+	// ClosedStatuses are the Jira statuses that count as closed.
+	ClosedStatuses []string
+	// CloseStatus is the Jira status to move to when Github closes.
+	CloseStatus string
+	// ReopenStatus is the Jira status to move to when Github reopens.
+	ReopenStatus string
+}
+
+// IsClosed returns true if the Jira status counts as closed.
+func (m Mapping) IsClosed(status string) bool {
+	for _, s := range m.ClosedStatuses {
+		if strings.EqualFold(s, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pair is a Github issue and its Jira clone.
+type Pair struct {
+	Clone         jira.ClonedIssue
+	GithubState   string
+	GithubUpdated time.Time
+
+	issue *github.Issue
+}
+
+// Action is a change that brings one side of a Pair in line with the other.
+type Action struct {
+	Pair Pair
+	// Github is the state to set on the Github issue, empty if unchanged.
+	Github string
+	// Jira is the status to move the Jira issue to, empty if unchanged.
+	Jira string
+}
+
+func (a Action) String() string {
+	if a.Github != "" {
+		verb := "reopen"
+		if a.Github == "closed" {
+			verb = "close"
+		}
+		return fmt.Sprintf("%s %s (%s is %s)", verb, a.Pair.Clone.GithubURL(),
+			a.Pair.Clone.Key, a.Pair.Clone.Status)
+	}
+	return fmt.Sprintf("transition %s from %s to %s (%s is %s)", a.Pair.Clone.Key,
+		a.Pair.Clone.Status, a.Jira, a.Pair.Clone.GithubURL(), a.Pair.GithubState)
+}
+
+// Plan returns the actions needed to reconcile the given pairs.
+func Plan(pairs []Pair, m Mapping, d Direction) []Action {
+	var actions []Action
+	for _, p := range pairs {
+		githubClosed := p.GithubState == "closed"
+		jiraClosed := m.IsClosed(p.Clone.Status)
+		if githubClosed == jiraClosed {
+			continue
+		}
+
+		githubWins := d == FromGithub ||
+			(d == Both && p.GithubUpdated.After(p.Clone.Updated))
+
+		action := Action{Pair: p}
+		switch {
+		case githubWins && githubClosed:
+			action.Jira = m.CloseStatus
+		case githubWins:
+			action.Jira = m.ReopenStatus
+		case jiraClosed:
+			action.Github = "closed"
+		default:
+			action.Github = "open"
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+type Option func(*SyncerConfig) error
+
+type SyncerConfig struct {
+	dryRun     bool
+	direction  Direction
+	mapping    Mapping
+	links      links.Store
+	githubOpts []gh.Option
+	jiraOpts   []jira.Option
+}
+
+func (c *SyncerConfig) setDefaults() error {
+	if c.direction == "" {
+		c.direction = FromGithub
+	}
+	if c.mapping.CloseStatus == "" || c.mapping.ReopenStatus == "" {
+		return fmt.Errorf("the sync mapping needs both a close and a reopen status")
+	}
+	return nil
+}
+
+func WithDryRun(dr bool) Option {
+	return func(c *SyncerConfig) error {
+		c.dryRun = dr
+		return nil
+	}
+}
+
+func WithDirection(d Direction) Option {
+	return func(c *SyncerConfig) error {
+		for _, valid := range Directions {
+			if d == valid {
+				c.direction = d
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid direction %q, must be one of %v", d, Directions)
+	}
+}
+
+func WithMapping(m Mapping) Option {
+	return func(c *SyncerConfig) error {
+		c.mapping = m
+		return nil
+	}
+}
+
+// WithLinks also syncs the pairs recorded in the link store, such as issues
+// linked by hand whose Jira description has no upstream link.
+func WithLinks(store links.Store) Option {
+	return func(c *SyncerConfig) error {
+		c.links = store
+		return nil
+	}
+}
+
+// WithGithubOptions adds Github options.
+func WithGithubOptions(opts ...gh.Option) Option {
+	return func(c *SyncerConfig) error {
+		c.githubOpts = append(c.githubOpts, opts...)
+		return nil
+	}
+}
+
+// WithJiraOptions adds Jira options.
+func WithJiraOptions(opts ...jira.Option) Option {
+	return func(c *SyncerConfig) error {
+		c.jiraOpts = append(c.jiraOpts, opts...)
+		return nil
+	}
+}
+
+// Sync compares every Jira clone with its Github issue and applies the actions.
+func Sync(opts ...Option) ([]Action, error) {
+	config := SyncerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	clones, err := jira.FindClones(config.jiraOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if config.links != nil {
+		linked, err := config.findLinked(clones)
+		if err != nil {
+			return nil, err
+		}
+		clones = append(clones, linked...)
+	}
+
+	var failed []string
+	pairs := make([]Pair, 0, len(clones))
+	for _, clone := range clones {
+		issue, err := gh.GetIssue(clone.Number, config.githubOptions(clone)...)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("unable to get %s for %s: %v", clone.GithubURL(), clone.Key, err))
+			continue
+		}
+		pairs = append(pairs, Pair{
+			Clone:         clone,
+			GithubState:   issue.GetState(),
+			GithubUpdated: issue.GetUpdatedAt(),
+			issue:         issue,
+		})
+	}
+
+	actions := Plan(pairs, config.mapping, config.direction)
+
+	if config.dryRun {
+		fmt.Println("\n############# DRY RUN MODE #############")
+		fmt.Printf("Checked %d cloned issues, %d out of sync\n\n", len(pairs), len(actions))
+		for _, a := range actions {
+			fmt.Printf("Would %s\n", a)
+		}
+		fmt.Println("\n############# DRY RUN MODE #############")
+		return actions, failedError(failed)
+	}
+
+	for _, a := range actions {
+		fmt.Printf("Syncing: %s\n", a)
+		issue := a.Pair.issue
+		if a.Github != "" {
+			var err error
+			if issue, err = gh.SetIssueState(a.Pair.Clone.Number, a.Github,
+				config.githubOptions(a.Pair.Clone)...); err != nil {
+				return actions, err
+			}
+		} else if err := jira.TransitionIssue(a.Pair.Clone.Key, a.Jira, config.jiraOpts...); err != nil {
+			return actions, err
+		}
+		// keep the state shown by the Jira remote link in step
+		if issue != nil {
+			if err := jira.SetRemoteLink(a.Pair.Clone.Key, issue, config.jiraOpts...); err != nil {
+				fmt.Printf("Warning: unable to update the remote link of %s: %v\n", a.Pair.Clone.Key, err)
+			}
+		}
+	}
+	return actions, failedError(failed)
+}
+
+// failedError returns an error listing the pairs that could not be synced,
+// nil if there are none.
+func failedError(failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d cloned issues could not be synced:\n  %s", len(failed), strings.Join(failed, "\n  "))
+}
+
+// findLinked returns the pairs of the link store that are not among the
+// clones found through their Jira description.
+func (c *SyncerConfig) findLinked(clones []jira.ClonedIssue) ([]jira.ClonedIssue, error) {
+	all, err := c.links.Find("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[links.Link]bool{}
+	for _, clone := range clones {
+		known[pairKey(links.GithubRef(clone.GithubProject(), clone.Number), clone.Key)] = true
+	}
+	var missing []links.Link
+	for _, l := range all {
+		if !known[pairKey(l.Github, l.Jira)] {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	return jira.FindLinked(missing, c.jiraOpts...)
+}
+
+// pairKey identifies a Github issue and Jira issue pair ignoring case.
+func pairKey(github string, jira string) links.Link {
+	return links.Link{Github: strings.ToLower(github), Jira: strings.ToUpper(jira)}
+}
+
+func (c *SyncerConfig) githubOptions(clone jira.ClonedIssue) []gh.Option {
+	opts := append([]gh.Option{}, c.githubOpts...)
+	return append(opts, gh.WithProject(clone.GithubProject()))
+}
@@ -0,0 +1,384 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/links"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var mapping = Mapping{
+	ClosedStatuses: []string{"Closed", "Done"},
+	CloseStatus:    "Closed",
+	ReopenStatus:   "New",
+}
+
+func pair(githubState string, githubUpdated time.Time, jiraStatus string, jiraUpdated time.Time) Pair {
+	return Pair{
+		Clone: jira.ClonedIssue{
+			Key:     "OSDK-1",
+			Status:  jiraStatus,
+			Updated: jiraUpdated,
+			Org:     "foo",
+			Repo:    "bar",
+			Number:  123,
+		},
+		GithubState:   githubState,
+		GithubUpdated: githubUpdated,
+	}
+}
+
+var _ = Describe("Syncer", func() {
+	var (
+		earlier = time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+		later   = time.Date(2022, 9, 2, 0, 0, 0, 0, time.UTC)
+	)
+
+	Describe("Mapping", func() {
+		It("should match closed statuses ignoring case", func() {
+			Expect(mapping.IsClosed("done")).To(BeTrue())
+			Expect(mapping.IsClosed("In Progress")).To(BeFalse())
+		})
+	})
+
+	Describe("Plan", func() {
+		It("should do nothing when both sides agree", func() {
+			pairs := []Pair{
+				pair("open", later, "New", earlier),
+				pair("closed", later, "Done", earlier),
+			}
+			Expect(Plan(pairs, mapping, Both)).To(BeEmpty())
+		})
+		It("should let the most recently updated side win", func() {
+			actions := Plan([]Pair{pair("closed", later, "New", earlier)}, mapping, Both)
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Jira).To(Equal("Closed"))
+			Expect(actions[0].Github).To(BeEmpty())
+
+			actions = Plan([]Pair{pair("closed", earlier, "New", later)}, mapping, Both)
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Github).To(Equal("open"))
+			Expect(actions[0].Jira).To(BeEmpty())
+		})
+		It("should always follow Github when asked", func() {
+			actions := Plan([]Pair{pair("open", earlier, "Closed", later)}, mapping, FromGithub)
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Jira).To(Equal("New"))
+		})
+		It("should always follow Jira when asked", func() {
+			actions := Plan([]Pair{pair("open", later, "Closed", earlier)}, mapping, FromJira)
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Github).To(Equal("closed"))
+			Expect(actions[0].String()).To(Equal(
+				"close https://github.com/foo/bar/issues/123 (OSDK-1 is Closed)"))
+		})
+	})
+
+	Context("With Option methods", func() {
+		var (
+			options SyncerConfig
+		)
+		BeforeEach(func() {
+			options = SyncerConfig{}
+		})
+		Describe("WithDirection", func() {
+			It("should set the direction", func() {
+				err := WithDirection(FromJira)(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.direction).To(Equal(FromJira))
+			})
+			It("should return an error for an unknown direction", func() {
+				err := WithDirection("sideways")(&options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		Describe("WithMapping", func() {
+			It("should set the mapping", func() {
+				err := WithMapping(mapping)(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.mapping).To(Equal(mapping))
+			})
+		})
+	})
+
+	Describe("Sync", func() {
+		var (
+			originalTokens map[string]string
+			jiraClient     *http.Client
+			transitioned   bool
+			remoteLinks    []gojira.RemoteLink
+		)
+		BeforeEach(func() {
+			originalTokens = map[string]string{}
+			for _, env := range []string{"GITHUB_TOKEN", "JIRA_TOKEN"} {
+				originalTokens[env] = os.Getenv(env)
+				Expect(os.Setenv(env, "blah-blah-blah")).To(Succeed())
+			}
+
+			transitioned = false
+			remoteLinks = nil
+			jiraClient = jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, map[string]interface{}{
+					"startAt":    0,
+					"maxResults": 50,
+					"total":      1,
+					"issues": []gojira.Issue{
+						{
+							Key: "OSDK-1",
+							Fields: &gojira.IssueFields{
+								Description: "body\n\nUpstream Github issue: " +
+									"https://github.com/foo/bar/issues/123\n",
+								Status:  &gojira.Status{Name: "New"},
+								Updated: gojira.Time(earlier),
+							},
+						},
+					},
+				}),
+				jmock.WithRequestMatch(jmock.GetIssueTransitionsByKey, map[string]interface{}{
+					"transitions": []gojira.Transition{
+						{ID: "11", Name: "Start", To: gojira.Status{Name: "In Progress"}},
+						{ID: "61", Name: "Close Issue", To: gojira.Status{Name: "Closed"}},
+					},
+				}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueTransitionsByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						transitioned = true
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueRemoteLinkByKey,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var link gojira.RemoteLink
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						remoteLinks = append(remoteLinks, link)
+						w.Write(jmock.MustMarshal(link))
+					}),
+				),
+			)
+		})
+		AfterEach(func() {
+			for env, value := range originalTokens {
+				Expect(os.Setenv(env, value)).To(Succeed())
+			}
+		})
+		It("should return error if Options return an error", func() {
+			_, err := Sync(func(c *SyncerConfig) error {
+				return fmt.Errorf("do you see me")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("do you see me"))
+		})
+		It("should transition the Jira clone of a closed Github issue", func() {
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					github.Issue{
+						Number:    github.Int(123),
+						State:     github.String("closed"),
+						UpdatedAt: &later,
+					},
+				),
+			)
+			actions, err := Sync(
+				WithMapping(mapping),
+				WithGithubOptions(gh.WithClient(githubClient)),
+				WithJiraOptions(jira.WithClient(jiraClient),
+					jira.WithJiraURL("http://localhost"),
+					jira.WithProject("OSDK")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Jira).To(Equal("Closed"))
+			Expect(transitioned).To(BeTrue())
+			Expect(remoteLinks).To(HaveLen(1))
+			Expect(remoteLinks[0].Object.Status.Resolved).To(BeTrue())
+		})
+		It("should also sync the pairs of the link store", func() {
+			dir, err := os.MkdirTemp("", "gh2jira-links")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := links.Open("json", filepath.Join(dir, "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+			for _, l := range []links.Link{
+				{Github: "foo/bar#123", Jira: "OSDK-1"},
+				{Github: "foo/bar#124", Jira: "OSDK-2"},
+				{Github: "foo/bar#125", Jira: "OLM-3"},
+			} {
+				_, err := store.Add(l)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			linkedClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, map[string]interface{}{
+					"startAt":    0,
+					"maxResults": 50,
+					"total":      1,
+					"issues": []gojira.Issue{
+						{
+							Key: "OSDK-1",
+							Fields: &gojira.IssueFields{
+								Description: "Upstream Github issue: https://github.com/foo/bar/issues/123\n",
+								Status:      &gojira.Status{Name: "New"},
+								Updated:     gojira.Time(earlier),
+							},
+						},
+					},
+				}),
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key: "OSDK-2",
+					Fields: &gojira.IssueFields{
+						Status:  &gojira.Status{Name: "Done"},
+						Updated: gojira.Time(later),
+					},
+				}),
+			)
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					github.Issue{Number: github.Int(123), State: github.String("open"), UpdatedAt: &earlier},
+					github.Issue{Number: github.Int(124), State: github.String("open"), UpdatedAt: &earlier},
+				),
+			)
+			actions, err := Sync(
+				WithDryRun(true),
+				WithDirection(FromJira),
+				WithMapping(mapping),
+				WithLinks(store),
+				WithGithubOptions(gh.WithClient(githubClient)),
+				WithJiraOptions(jira.WithClient(linkedClient),
+					jira.WithJiraURL("http://localhost"),
+					jira.WithProject("OSDK")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Pair.Clone.Key).To(Equal("OSDK-2"))
+			Expect(actions[0].Github).To(Equal("closed"))
+		})
+		It("should follow Github by default", func() {
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					github.Issue{Number: github.Int(123), State: github.String("closed"), UpdatedAt: &earlier},
+				),
+			)
+			actions, err := Sync(
+				WithDryRun(true),
+				WithMapping(mapping),
+				WithGithubOptions(gh.WithClient(githubClient)),
+				WithJiraOptions(jira.WithClient(jiraClient),
+					jira.WithJiraURL("http://localhost"),
+					jira.WithProject("OSDK")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Jira).To(Equal("Closed"))
+		})
+		It("should sync the other pairs when a Github issue is gone", func() {
+			dir, err := os.MkdirTemp("", "gh2jira-links")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := links.Open("json", filepath.Join(dir, "links.json"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = store.Add(links.Link{Github: "foo/bar#124", Jira: "OSDK-2"})
+			Expect(err).NotTo(HaveOccurred())
+
+			linkedClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch, map[string]interface{}{
+					"startAt":    0,
+					"maxResults": 50,
+					"total":      1,
+					"issues": []gojira.Issue{
+						{
+							Key: "OSDK-1",
+							Fields: &gojira.IssueFields{
+								Description: "Upstream Github issue: https://github.com/foo/bar/issues/123\n",
+								Status:      &gojira.Status{Name: "New"},
+							},
+						},
+					},
+				}),
+				jmock.WithRequestMatch(jmock.GetIssueByKey, gojira.Issue{
+					Key:    "OSDK-2",
+					Fields: &gojira.IssueFields{Status: &gojira.Status{Name: "New"}},
+				}),
+			)
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if strings.HasSuffix(r.URL.Path, "/123") {
+							mock.WriteError(w, http.StatusNotFound, "Not Found")
+							return
+						}
+						w.Write(mock.MustMarshal(github.Issue{Number: github.Int(124),
+							State: github.String("closed")}))
+					}),
+				),
+			)
+			actions, err := Sync(
+				WithDryRun(true),
+				WithMapping(mapping),
+				WithLinks(store),
+				WithGithubOptions(gh.WithClient(githubClient)),
+				WithJiraOptions(jira.WithClient(linkedClient),
+					jira.WithJiraURL("http://localhost"),
+					jira.WithProject("OSDK")),
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("1 cloned issues could not be synced:"))
+			Expect(err.Error()).To(ContainSubstring("https://github.com/foo/bar/issues/123 for OSDK-1"))
+			Expect(actions).To(HaveLen(1))
+			Expect(actions[0].Pair.Clone.Key).To(Equal("OSDK-2"))
+		})
+		It("should only plan the changes in dry run mode", func() {
+			githubClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					github.Issue{
+						Number:    github.Int(123),
+						State:     github.String("closed"),
+						UpdatedAt: &later,
+					},
+				),
+			)
+			actions, err := Sync(
+				WithDryRun(true),
+				WithMapping(mapping),
+				WithGithubOptions(gh.WithClient(githubClient)),
+				WithJiraOptions(jira.WithClient(jiraClient),
+					jira.WithJiraURL("http://localhost"),
+					jira.WithProject("OSDK")),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actions).To(HaveLen(1))
+			Expect(transitioned).To(BeFalse())
+			Expect(remoteLinks).To(BeEmpty())
+		})
+	})
+})
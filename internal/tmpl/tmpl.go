@@ -0,0 +1,187 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tmpl holds the Go templates used to render cloned Jira issues and
+// the helper functions available to them.
+package tmpl
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/markup"
+)
+
+// Template renders the summary and description of the Jira issues cloned
+// from the Github projects matching Project, an ORG/REPO or a glob such as
+// ORG/*. An empty Project matches every project, an empty Summary or
+// Description keeps the built-in one.
+type Template struct {
+	Name        string `yaml:"name"`
+	Project     string `yaml:"project,omitempty"`
+	Summary     string `yaml:"summary,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Validate returns an error if the template has no name, an invalid project
+// pattern or a summary or description that does not parse.
+func (t Template) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template is missing a name")
+	}
+	if _, err := path.Match(t.Project, ""); err != nil {
+		return fmt.Errorf("template %q: invalid project pattern: %w", t.Name, err)
+	}
+	if t.Summary == "" && t.Description == "" {
+		return fmt.Errorf("template %q does not set summary or description", t.Name)
+	}
+	if _, err := Parse("summary", t.Summary); err != nil {
+		return fmt.Errorf("template %q: %w", t.Name, err)
+	}
+	if _, err := Parse("description", t.Description); err != nil {
+		return fmt.Errorf("template %q: %w", t.Name, err)
+	}
+	return nil
+}
+
+// matches returns true if the template applies to the Github project.
+func (t Template) matches(project string) bool {
+	if t.Project == "" || strings.EqualFold(t.Project, project) {
+		return true
+	}
+	ok, _ := path.Match(t.Project, project)
+	return ok
+}
+
+// Select returns the template called name or, when name is empty, the first
+// template matching the Github project. The zero Template, meaning the
+// built-in summary and description, is returned when none matches.
+func Select(templates []Template, name string, project string) (Template, error) {
+	if name != "" {
+		names := make([]string, 0, len(templates))
+		for _, t := range templates {
+			if strings.EqualFold(t.Name, name) {
+				return t, nil
+			}
+			names = append(names, t.Name)
+		}
+		if len(names) == 0 {
+			return Template{}, fmt.Errorf("no template named %q, the config file has none", name)
+		}
+		return Template{}, fmt.Errorf("no template named %q, available: %s", name, strings.Join(names, ", "))
+	}
+	for _, t := range templates {
+		if t.matches(project) {
+			return t, nil
+		}
+	}
+	return Template{}, nil
+}
+
+// Funcs are the helper functions available to every template.
+var Funcs = template.FuncMap{
+	"jira":     markup.ToJira,
+	"labels":   labels,
+	"join":     join,
+	"date":     date,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"trim":     strings.TrimSpace,
+	"truncate": truncate,
+	"default":  defaultValue,
+}
+
+// Parse parses text as a template with the helper functions.
+func Parse(name string, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(Funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+// labels returns the names of the Github labels.
+func labels(ls []*github.Label) []string {
+	names := make([]string, 0, len(ls))
+	for _, l := range ls {
+		names = append(names, l.GetName())
+	}
+	return names
+}
+
+// join joins the values with sep, it takes the list last so it can be piped.
+func join(sep string, values []string) string {
+	return strings.Join(values, sep)
+}
+
+// date formats a time with the Go layout, e.g. 2006-01-02. A missing time is
+// an empty string.
+func date(layout string, t interface{}) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return "", nil
+		}
+		return v.Format(layout), nil
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		return date(layout, *v)
+	case github.Timestamp:
+		return date(layout, v.Time)
+	case *github.Timestamp:
+		if v == nil {
+			return "", nil
+		}
+		return date(layout, v.Time)
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("date: %T is not a time", t)
+}
+
+// truncate shortens s to at most n characters, ending it with ... when cut.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// defaultValue returns value, or def when value is empty.
+func defaultValue(def string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case []string:
+		if len(v) == 0 {
+			return def
+		}
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tmpl
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func execute(text string, data interface{}) (string, error) {
+	t, err := Parse("test", text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	return buf.String(), err
+}
+
+var _ = Describe("Tmpl", func() {
+	Describe("Validate", func() {
+		It("should accept a valid template", func() {
+			t := Template{Name: "olm", Project: "operator-framework/*", Summary: "{{ .GetTitle | upper }}"}
+			Expect(t.Validate()).To(Succeed())
+		})
+		It("should require a name", func() {
+			Expect(Template{Summary: "x"}.Validate()).To(MatchError("template is missing a name"))
+		})
+		It("should require a summary or description", func() {
+			Expect(Template{Name: "olm"}.Validate()).To(
+				MatchError(`template "olm" does not set summary or description`))
+		})
+		It("should reject an invalid project pattern", func() {
+			Expect(Template{Name: "olm", Project: "[", Summary: "x"}.Validate()).To(HaveOccurred())
+		})
+		It("should reject an unknown function", func() {
+			err := Template{Name: "olm", Description: "{{ .GetBody | markdown }}"}.Validate()
+			Expect(err).To(MatchError(ContainSubstring("invalid description template")))
+		})
+	})
+	Describe("Select", func() {
+		templates := []Template{
+			{Name: "olm", Project: "operator-framework/operator-lifecycle-manager", Summary: "[OLM]"},
+			{Name: "framework", Project: "operator-framework/*", Summary: "[OF]"},
+			{Name: "short", Summary: "short"},
+		}
+		It("should pick the first template matching the project", func() {
+			t, err := Select(templates, "", "operator-framework/operator-lifecycle-manager")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name).To(Equal("olm"))
+
+			t, err = Select(templates, "", "operator-framework/operator-sdk")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name).To(Equal("framework"))
+
+			t, err = Select(templates, "", "foo/bar")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name).To(Equal("short"))
+		})
+		It("should pick the template by name", func() {
+			t, err := Select(templates, "Short", "operator-framework/operator-sdk")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name).To(Equal("short"))
+		})
+		It("should fail for an unknown name", func() {
+			_, err := Select(templates, "nope", "")
+			Expect(err).To(MatchError(`no template named "nope", available: olm, framework, short`))
+
+			_, err = Select(nil, "nope", "")
+			Expect(err).To(MatchError(`no template named "nope", the config file has none`))
+		})
+		It("should return the built-in template when none matches", func() {
+			t, err := Select(templates[:1], "", "foo/bar")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).To(Equal(Template{}))
+		})
+	})
+	Describe("Funcs", func() {
+		created := time.Date(2022, 6, 30, 15, 4, 5, 0, time.UTC)
+		issue := &github.Issue{
+			Title:     github.String("Fix the thing"),
+			Body:      github.String("## Steps\n\n`make test`"),
+			CreatedAt: &created,
+			Labels: []*github.Label{
+				{Name: github.String("kind/bug")},
+				{Name: github.String("area/helm")},
+			},
+			Reactions: &github.Reactions{PlusOne: github.Int(3)},
+		}
+
+		It("should convert the body to Jira markup", func() {
+			out, err := execute("{{ .GetBody | jira }}", issue)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("h2. Steps\n\n{{make test}}"))
+		})
+		It("should join the label names", func() {
+			out, err := execute(`{{ labels .Labels | join ", " }}`, issue)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("kind/bug, area/helm"))
+		})
+		It("should format dates", func() {
+			out, err := execute(`{{ date "2006-01-02" .CreatedAt }} {{ date "2006-01-02" .GetClosedAt }}.`, issue)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("2022-06-30 ."))
+
+			_, err = execute(`{{ date "2006" .GetTitle }}`, issue)
+			Expect(err).To(MatchError(ContainSubstring("string is not a time")))
+		})
+		It("should reach the reactions", func() {
+			out, err := execute("{{ .GetReactions.GetPlusOne }}", issue)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("3"))
+		})
+		It("should truncate, change case and default", func() {
+			out, err := execute(`{{ .GetTitle | truncate 8 | upper }} `+
+				`{{ .GetMilestone.GetTitle | default "none" }} {{ "  X " | trim | lower }}`, issue)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("FIX T... none x"))
+		})
+	})
+})
@@ -32,7 +32,7 @@ import (
 // global flags
 // --no-color
 // --oneline
-// gh2jira genconfig
+// gh2jira genconfig [--interactive]
 // gh2jira list --project operator-framework/operator-sdk [--milestone=] [--assignee=]
 // gh2jira clone GH# [--dry-run]
 func main() {